@@ -7,6 +7,7 @@ import (
 	"runtime/debug"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/bayashi/xfg/internal/xfglangxt"
 	flag "github.com/spf13/pflag"
@@ -21,7 +22,17 @@ const (
 	defaultIndent         string = " "
 	defaultMaxDepth       uint32 = 255
 
+	stdinStartPath string = "-"
+	stdinPathName  string = "<stdin>"
+
 	streamResultChanBufferSize int = 100
+	spillResultThresholdBytes  int = 64 * 1024 * 1024
+
+	binaryCheckBytes int = 8000
+
+	// Only very large files are mapped by default, because a mapped file which is truncated by another process
+	// like log rotation crashes the process with SIGBUS. Reading is fast enough for smaller files
+	mmapThresholdBytes int64 = 64 * 1024 * 1024
 )
 
 var (
@@ -36,7 +47,8 @@ type optionsExtra struct {
 	withAfterContextLines    bool
 	withBeforeContextLines   bool
 
-	runWithNoArg bool
+	runWithNoArg     bool
+	searchStartGiven bool
 
 	onlyMatchContent bool
 }
@@ -55,12 +67,27 @@ type options struct {
 	ColorPath      string `toml:"color-path"`
 	ColorContent   string `toml:"color-conetnt"`
 	XfgIgnoreFile  string `toml:"xfgignore-file"`
+	FilesFrom      string `toml:"files-from"`
 
 	Ignore []string `toml:"ignore"`
 
-	Type string   `toml:"Type"`
-	Lang []string `toml:"lang"`
-	Ext  []string `toml:"ext"`
+	Type  stringList `toml:"type"`
+	Owner string     `toml:"owner"`
+	Sort  string     `toml:"sort"`
+	SortR string     `toml:"sortr"`
+	Perm  []string   `toml:"perm"`
+	Lang  []string   `toml:"lang"`
+	Ext   []string   `toml:"ext"`
+
+	LineEnding []string `toml:"line-ending"`
+	Lines      []string `toml:"lines"`
+	Size       []string `toml:"size"`
+
+	MaxFilesize   string `toml:"max-filesize"`
+	ChangedWithin string `toml:"changed-within"`
+	ChangedBefore string `toml:"changed-before"`
+	Newer         string `toml:"newer"`
+	Older         string `toml:"older"`
 
 	IgnoreCase             bool `toml:"ignore-case"`
 	KeepResultOrder        bool `toml:"keep-result-order"`
@@ -72,6 +99,9 @@ type options struct {
 	Hidden                 bool `toml:"hidden"`
 	SkipGitIgnore          bool `toml:"skip-gitignore"`
 	SkipXfgIgnore          bool `toml:"skip-xfgignore"`
+	SkipDotIgnore          bool `toml:"skip-dot-ignore"`
+	SkipRgIgnore           bool `toml:"skip-rgignore"`
+	SkipFdIgnore           bool `toml:"skip-fdignore"`
 	NoDefaultSkip          bool `toml:"no-default-skip"`
 	SearchDefaultSkipStuff bool `toml:"search-default-skip-stuff"`
 	SearchAll              bool `toml:"search-all"`
@@ -80,14 +110,29 @@ type options struct {
 	Quiet                  bool `toml:"quiet"`
 	FilesWithMatches       bool `toml:"files-with-matches"`
 	Null                   bool `toml:"null"`
+	NullData               bool `toml:"null-data"`
 	Stats                  bool `toml:"stats"`
 	SearchOnlyName         bool `toml:"search-only-name"`
 	NotWordBoundary        bool `toml:"not-word-boundary"`
 	IgnorePermissionError  bool `toml:"ignore-permission-error"`
+	SkipLongLineFile       bool `toml:"skip-long-line-file"`
 	NoFilename             bool `toml:"no-filename"`
 	NoLineNumber           bool `toml:"no-line-number"`
-
-	flagLangList bool
+	CRLF                   bool `toml:"crlf"`
+	Follow                 bool `toml:"follow"`
+	Setuid                 bool `toml:"setuid"`
+	Setgid                 bool `toml:"setgid"`
+	OneFileSystem          bool `toml:"one-file-system"`
+	ShowDepth              bool `toml:"show-depth"`
+	OrderedStream          bool `toml:"ordered-stream"`
+	Deterministic          bool `toml:"deterministic"`
+	SortNatural            bool `toml:"sort-natural"`
+	DirsFirst              bool `toml:"dirs-first"`
+	Mmap                   bool `toml:"mmap"`
+	NoMmap                 bool `toml:"no-mmap"`
+
+	flagLangList    bool
+	flagDebugIgnore string
 
 	ContextLines uint32 `toml:"context"`
 
@@ -97,6 +142,12 @@ type options struct {
 	MaxMatchCount uint32 `toml:"max-count"`
 	MaxColumns    uint32 `toml:"max-columns"`
 	MaxDepth      uint32 `toml:"max-depth"`
+	MinDepth      uint32 `toml:"min-depth"`
+	MaxLineBytes  uint32 `toml:"max-line-bytes"`
+	Threads       uint32 `toml:"threads"`
+	MaxResults    uint32 `toml:"max-results"`
+
+	Timeout time.Duration `toml:"timeout"`
 
 	extra optionsExtra
 }
@@ -105,13 +156,22 @@ func (o *options) falgs(d *options) {
 	flag.StringArrayVarP(&o.SearchPath, "path", "p", d.SearchPath, getMessage("help_SearchPath"))
 	flag.StringArrayVarP(&o.SearchGrep, "grep", "g", d.SearchGrep, getMessage("help_SearchGrep"))
 	flag.StringArrayVarP(&o.SearchStart, "start", "s", d.SearchStart, getMessage("help_SearchStart"))
+	flag.StringVarP(&o.FilesFrom, "files-from", "", d.FilesFrom, getMessage("help_FilesFrom"))
+	flag.BoolVarP(&o.NullData, "null-data", "", d.NullData, getMessage("help_NullData"))
 
 	flag.BoolVarP(&o.IgnoreCase, "ignore-case", "i", d.IgnoreCase, getMessage("help_IgnoreCase"))
 	flag.BoolVarP(&o.KeepResultOrder, "keep-result-order", "", d.KeepResultOrder, getMessage("help_KeepResultOrder"))
+	flag.BoolVarP(&o.OrderedStream, "ordered-stream", "", d.OrderedStream, getMessage("help_OrderedStream"))
+	flag.BoolVarP(&o.Deterministic, "deterministic", "", d.Deterministic, getMessage("help_Deterministic"))
+	flag.StringVarP(&o.Sort, "sort", "", d.Sort, getMessage("help_Sort"))
+	flag.StringVarP(&o.SortR, "sortr", "", d.SortR, getMessage("help_SortR"))
+	flag.BoolVarP(&o.SortNatural, "sort-natural", "", d.SortNatural, getMessage("help_SortNatural"))
+	flag.BoolVarP(&o.DirsFirst, "dirs-first", "", d.DirsFirst, getMessage("help_DirsFirst"))
 
 	flag.StringArrayVarP(&o.SearchPathRe, "path-regexp", "P", d.SearchPathRe, getMessage("help_SearchPathRe"))
 	flag.StringArrayVarP(&o.SearchGrepRe, "grep-regexp", "G", d.SearchGrepRe, getMessage("help_SearchGrepRe"))
 	flag.BoolVarP(&o.NotWordBoundary, "not-word-boundary", "M", d.NotWordBoundary, getMessage("help_NotWordBoundary"))
+	flag.StringArrayVarP(&o.Lines, "lines", "", d.Lines, getMessage("help_Lines"))
 
 	flag.Uint32VarP(&o.ContextLines, "context", "C", d.ContextLines, getMessage("help_ContextLines"))
 	flag.Uint32VarP(&o.AfterContextLines, "after-context", "A", d.AfterContextLines, getMessage("help_AfterContextLines"))
@@ -120,23 +180,49 @@ func (o *options) falgs(d *options) {
 	flag.BoolVarP(&o.Hidden, "hidden", ".", d.Hidden, getMessage("help_Hidden"))
 	flag.BoolVarP(&o.SkipGitIgnore, "skip-gitignore", "", d.SkipGitIgnore, getMessage("help_SkipGitIgnore"))
 	flag.BoolVarP(&o.SkipXfgIgnore, "skip-xfgignore", "", d.SkipXfgIgnore, getMessage("help_SkipXfgIgnore"))
+	flag.BoolVarP(&o.SkipDotIgnore, "skip-dot-ignore", "", d.SkipDotIgnore, getMessage("help_SkipDotIgnore"))
+	flag.BoolVarP(&o.SkipRgIgnore, "skip-rgignore", "", d.SkipRgIgnore, getMessage("help_SkipRgIgnore"))
+	flag.BoolVarP(&o.SkipFdIgnore, "skip-fdignore", "", d.SkipFdIgnore, getMessage("help_SkipFdIgnore"))
 	flag.BoolVarP(&o.NoDefaultSkip, "no-default-skip", "", d.NoDefaultSkip, getMessage("help_NoDefaultSkip"))
 	flag.BoolVarP(&o.SearchDefaultSkipStuff, "search-default-skip-stuff", "n", d.SearchDefaultSkipStuff, getMessage("help_SearchDefaultSkipStuff"))
 	flag.BoolVarP(&o.SearchAll, "search-all", "a", d.SearchAll, getMessage("help_SearchAll"))
 	flag.BoolVarP(&o.Unrestricted, "unrestricted", "u", d.Unrestricted, getMessage("help_Unrestricted"))
 	flag.StringArrayVarP(&o.Ignore, "ignore", "", d.Ignore, getMessage("help_Ignore"))
 	flag.BoolVarP(&o.SearchOnlyName, "search-only-name", "f", d.SearchOnlyName, getMessage("help_SearchOnlyName"))
-
-	flag.StringVarP(&o.Type, "type", "t", d.Type, getMessage("help_Type"))
+	flag.BoolVarP(&o.Follow, "follow", "L", d.Follow, getMessage("help_Follow"))
+	flag.BoolVarP(&o.OneFileSystem, "one-file-system", "", d.OneFileSystem, getMessage("help_OneFileSystem"))
+
+	flag.StringArrayVarP((*[]string)(&o.Type), "type", "t", d.Type, getMessage("help_Type"))
+	flag.StringVarP(&o.Owner, "owner", "", d.Owner, getMessage("help_Owner"))
+	flag.StringArrayVarP(&o.Perm, "perm", "", d.Perm, getMessage("help_Perm"))
+	flag.BoolVarP(&o.Setuid, "setuid", "", d.Setuid, getMessage("help_Setuid"))
+	flag.BoolVarP(&o.Setgid, "setgid", "", d.Setgid, getMessage("help_Setgid"))
 	flag.StringArrayVarP(&o.Ext, "ext", "", d.Ext, getMessage("help_Ext"))
 	flag.StringArrayVarP(&o.Lang, "lang", "", d.Lang, getMessage("help_Lang"))
+	flag.StringArrayVarP(&o.Size, "size", "", d.Size, getMessage("help_Size"))
+	flag.StringVarP(&o.ChangedWithin, "changed-within", "", d.ChangedWithin, getMessage("help_ChangedWithin"))
+	flag.StringVarP(&o.ChangedBefore, "changed-before", "", d.ChangedBefore, getMessage("help_ChangedBefore"))
+	flag.StringVarP(&o.Newer, "newer", "", d.Newer, getMessage("help_Newer"))
+	flag.StringVarP(&o.Older, "older", "", d.Older, getMessage("help_Older"))
 	flag.BoolVarP(&o.flagLangList, "lang-list", "", false, getMessage("help_flagLangList"))
+	flag.StringVarP(&o.flagDebugIgnore, "debug-ignore", "", "", getMessage("help_flagDebugIgnore"))
+	flag.StringArrayVarP(&o.LineEnding, "line-ending", "", d.LineEnding, getMessage("help_LineEnding"))
+	flag.BoolVarP(&o.CRLF, "crlf", "", d.CRLF, getMessage("help_CRLF"))
 
 	flag.BoolVarP(&o.Abs, "abs", "", d.Abs, getMessage("help_Abs"))
 	flag.BoolVarP(&o.ShowMatchCount, "count", "c", d.ShowMatchCount, getMessage("help_ShowMatchCount"))
 	flag.Uint32VarP(&o.MaxMatchCount, "max-count", "m", d.MaxMatchCount, getMessage("help_MaxMatchCount"))
 	flag.Uint32VarP(&o.MaxColumns, "max-columns", "", d.MaxColumns, getMessage("help_MaxColumns"))
 	flag.Uint32VarP(&o.MaxDepth, "max-depth", "", d.MaxDepth, getMessage("help_MaxDepth"))
+	flag.Uint32VarP(&o.MinDepth, "min-depth", "", d.MinDepth, getMessage("help_MinDepth"))
+	flag.BoolVarP(&o.ShowDepth, "show-depth", "", d.ShowDepth, getMessage("help_ShowDepth"))
+	flag.Uint32VarP(&o.MaxLineBytes, "max-line-bytes", "", d.MaxLineBytes, getMessage("help_MaxLineBytes"))
+	flag.Uint32VarP(&o.MaxResults, "max-results", "", d.MaxResults, getMessage("help_MaxResults"))
+	flag.DurationVarP(&o.Timeout, "timeout", "", d.Timeout, getMessage("help_Timeout"))
+	flag.StringVarP(&o.MaxFilesize, "max-filesize", "", d.MaxFilesize, getMessage("help_MaxFilesize"))
+	flag.BoolVarP(&o.SkipLongLineFile, "skip-long-line-file", "", d.SkipLongLineFile, getMessage("help_SkipLongLineFile"))
+	flag.BoolVarP(&o.Mmap, "mmap", "", d.Mmap, getMessage("help_Mmap"))
+	flag.BoolVarP(&o.NoMmap, "no-mmap", "", d.NoMmap, getMessage("help_NoMmap"))
 	flag.BoolVarP(&o.FilesWithMatches, "files-with-matches", "l", d.FilesWithMatches, getMessage("help_FilesWithMatches"))
 	flag.BoolVarP(&o.Null, "null", "0", d.Null, getMessage("help_Null"))
 
@@ -161,6 +247,7 @@ func (o *options) falgs(d *options) {
 	flag.BoolVarP(&o.NoPager, "no-pager", "", d.NoPager, getMessage("help_NoPager"))
 	flag.BoolVarP(&o.Quiet, "quiet", "q", d.Quiet, getMessage("help_Quiet"))
 	flag.BoolVarP(&o.Stats, "stats", "", d.Stats, getMessage("help_Stats"))
+	flag.Uint32VarP(&o.Threads, "threads", "j", d.Threads, getMessage("help_Threads"))
 }
 
 func (cli *runner) parseArgs(d *options) *options {
@@ -175,9 +262,17 @@ func (cli *runner) parseArgs(d *options) *options {
 	flag.BoolVarP(&flagVersion, "version", "v", false, getMessage("help_Version"))
 	flag.Parse()
 
-	if o.Type != "" && !o.validateType() {
-		cli.putErr(fmt.Sprintf("wrong type `%s`. Supported: %s", o.Type, supportTypes))
-		funcExit(exitErr)
+	o.extra.searchStartGiven = flag.CommandLine.Changed("start")
+	o.preferCommandLine(flag.CommandLine.Changed)
+
+	if len(o.Type) > 0 {
+		o.Type = splitTypes(o.Type)
+		for _, t := range o.Type {
+			if !validateType(t) {
+				cli.putErr(fmt.Sprintf("wrong type `%s`. Supported: %s", t, supportTypes))
+				funcExit(exitErr)
+			}
+		}
 	}
 
 	if flagHelp {
@@ -195,12 +290,45 @@ func (cli *runner) parseArgs(d *options) *options {
 	return o
 }
 
-func (o *options) validateType() bool {
-	if len(o.Type) == 1 && strings.Contains("fdlxespbc", o.Type) {
+// preferCommandLine clears an option of .xfgrc which conflicts with an option given on the command line.
+// Both options on the command line are still an error on validation
+func (o *options) preferCommandLine(changed func(name string) bool) {
+	if changed("sort") && !changed("sortr") {
+		o.SortR = ""
+	}
+	if changed("sortr") && !changed("sort") {
+		o.Sort = ""
+	}
+	if changed("mmap") && !changed("no-mmap") {
+		o.NoMmap = false
+	}
+	if changed("no-mmap") && !changed("mmap") {
+		o.Mmap = false
+	}
+}
+
+// splitTypes splits comma separated types like "f,l"
+func splitTypes(types []string) []string {
+	var splitted []string
+	for _, t := range types {
+		for _, st := range strings.Split(t, ",") {
+			if st = strings.TrimSpace(st); st != "" {
+				splitted = append(splitted, st)
+			}
+		}
+	}
+
+	return splitted
+}
+
+// validateType validates a type. A type can be negated by prefix '!'
+func validateType(t string) bool {
+	t = strings.TrimPrefix(t, "!")
+	if len(t) == 1 && strings.Contains("fdlxespbc", t) {
 		return true // fine!
 	}
-	if o.Type == "directory" || o.Type == "symlink" || o.Type == "executable" || o.Type == "empty" ||
-		o.Type == "socket" || o.Type == "pipe" || o.Type == "block-device" || o.Type == "char-device" {
+	if t == "file" || t == "directory" || t == "symlink" || t == "executable" || t == "empty" ||
+		t == "socket" || t == "pipe" || t == "block-device" || t == "char-device" {
 		return true // fine!
 	}
 
@@ -255,21 +383,81 @@ func (o *options) prepareFromENV() {
 	}
 }
 
+// stringList is a list of strings in .xfgrc. It also accepts a string like `type = "d"`,
+// which was the format before the option could be given several times
+type stringList []string
+
+func (sl *stringList) UnmarshalTOML(v any) error {
+	switch v := v.(type) {
+	case string:
+		*sl = stringList{v}
+	case []any:
+		list := make(stringList, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("expected a string, but got %T", e)
+			}
+			list = append(list, s)
+		}
+		*sl = list
+	default:
+		return fmt.Errorf("expected a string or an array of strings, but got %T", v)
+	}
+
+	return nil
+}
+
 func (o *options) prepareAliases() {
 	if o.Unrestricted {
 		o.SearchAll = true
 	}
+
+	if o.OrderedStream {
+		o.KeepResultOrder = false // stream in order instead
+	}
+
+	if o.Sort != "" || o.SortR != "" || o.SortNatural || o.DirsFirst {
+		o.KeepResultOrder = true // need all results to sort
+		o.OrderedStream = false
+	}
+
+	if o.CRLF {
+		o.LineEnding = append(o.LineEnding, lineEndingCRLF, lineEndingMixed)
+	}
+}
+
+// Search standard input instead of walking directories when it is piped and no start path is given.
+// A path keyword is for walking, because it never matches `<stdin>`
+func (o *options) prepareStdin(isPipedIn bool) {
+	if isPipedIn && !o.extra.searchStartGiven && o.FilesFrom == "" && len(o.SearchPath) == 0 && o.hasGrepKeyword() {
+		o.SearchStart = []string{stdinStartPath}
+	}
+}
+
+func (o *options) hasGrepKeyword() bool {
+	return len(o.SearchGrep) > 0 || len(o.SearchGrepRe) > 0
 }
 
 func (o *options) prepareRuntimeFlags() {
-	if len(o.SearchGrep) > 0 || len(o.SearchGrepRe) > 0 {
+	if o.hasGrepKeyword() {
 		o.extra.onlyMatchContent = true
 	}
 }
 
 func (o *options) validateOptions() error {
-	if err := validateStartPath(o.SearchStart); err != nil {
-		return err
+	if o.FilesFrom != "" {
+		if o.extra.searchStartGiven {
+			return fmt.Errorf("could not use both --files-from and --start")
+		}
+	} else {
+		if err := validateStartPath(o.SearchStart); err != nil {
+			return err
+		}
+
+		if isStdinStart(o.SearchStart) && !o.hasGrepKeyword() {
+			return fmt.Errorf("need a keyword to grep contents to search for standard input")
+		}
 	}
 
 	if len(o.Lang) > 0 {
@@ -278,6 +466,25 @@ func (o *options) validateOptions() error {
 		}
 	}
 
+	if len(o.LineEnding) > 0 {
+		if err := validateLineEnding(o.LineEnding); err != nil {
+			return err
+		}
+	}
+
+	if o.Sort != "" && o.SortR != "" {
+		return fmt.Errorf("could not use both --sort and --sortr")
+	}
+	if key, _ := o.sortKey(); key != "" {
+		if err := validateSortKey(key); err != nil {
+			return err
+		}
+	}
+
+	if o.Mmap && o.NoMmap {
+		return fmt.Errorf("could not use both --mmap and --no-mmap")
+	}
+
 	return nil
 }
 
@@ -325,6 +532,8 @@ func getVersion() string {
 }
 
 func (cli *runner) putErr(message ...interface{}) {
+	cli.errMu.Lock()
+	defer cli.errMu.Unlock()
 	fmt.Fprintln(cli.err, message...)
 }
 
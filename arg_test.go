@@ -123,6 +123,12 @@ func TestArgs(t *testing.T) {
 				o.SearchGrepRe = []string{"fo."}
 			},
 		},
+		"multiple types": {
+			args: []string{"-t", "f,l", "--type", "!e"},
+			prepareExpect: func(o *options) {
+				o.Type = []string{"f", "l", "!e"}
+			},
+		},
 		"--no-line-number": {
 			args: []string{"--no-line-number"},
 			prepareExpect: func(o *options) {
@@ -153,6 +159,74 @@ func TestArgs(t *testing.T) {
 	}
 }
 
+func TestArgs_PreferCommandLine(t *testing.T) {
+	for tname, tt := range map[string]struct {
+		rc            func(o *options)
+		args          []string
+		prepareExpect func(o *options)
+	}{
+		"--sortr over sort of .xfgrc": {
+			rc:   func(o *options) { o.Sort = "path" },
+			args: []string{"--sortr", "size"},
+			prepareExpect: func(o *options) {
+				o.SortR = "size"
+			},
+		},
+		"--sort over sortr of .xfgrc": {
+			rc:   func(o *options) { o.SortR = "size" },
+			args: []string{"--sort", "name"},
+			prepareExpect: func(o *options) {
+				o.Sort = "name"
+			},
+		},
+		"--mmap over no-mmap of .xfgrc": {
+			rc:   func(o *options) { o.NoMmap = true },
+			args: []string{"--mmap"},
+			prepareExpect: func(o *options) {
+				o.Mmap = true
+			},
+		},
+		"--no-mmap over mmap of .xfgrc": {
+			rc:   func(o *options) { o.Mmap = true },
+			args: []string{"--no-mmap"},
+			prepareExpect: func(o *options) {
+				o.NoMmap = true
+			},
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			resetFlag()
+			stubExit()
+			os.Args = append([]string{fakeCmd}, tt.args...)
+			rc := defaultOptions()
+			tt.rc(rc)
+			o := (&runner{}).parseArgs(rc)
+
+			expectOptions := defaultOptions()
+			tt.prepareExpect(expectOptions)
+
+			a.Got(o).Expect(expectOptions).Same(t)
+			a.Got(o.validateOptions()).NoError(t)
+		})
+	}
+}
+
+func TestArgsWrongType(t *testing.T) {
+	var errOutput bytes.Buffer
+	cli := &runner{
+		err: &errOutput,
+	}
+
+	resetFlag()
+	stubExit()
+	os.Args = []string{fakeCmd, "--type", "f,z"}
+	cli.parseArgs(defaultOptions())
+
+	a.Got(stubCalled).True(t)
+	a.Got(stubCode).Expect(exitErr).Same(t)
+	a.Got(errOutput.String()).Expect("wrong type `z`").Match(t)
+}
+
 func TestPrepareAliases(t *testing.T) {
 	t.Parallel()
 	o := &options{
@@ -160,6 +234,43 @@ func TestPrepareAliases(t *testing.T) {
 	}
 	o.prepareAliases()
 	a.Got(o.SearchAll).True(t)
+
+	o = &options{
+		OrderedStream: true,
+		SortR:         "mtime",
+	}
+	o.prepareAliases()
+	a.Got(o.KeepResultOrder).True(t)
+	a.Got(o.OrderedStream).False(t)
+
+	o = &options{
+		Deterministic: true,
+		SortR:         "mtime",
+	}
+	o.prepareAliases()
+	a.Got(o.KeepResultOrder).True(t)
+	a.Got(o.Deterministic).True(t)
+}
+
+func TestValidateOptions_Sort(t *testing.T) {
+	t.Parallel()
+	o := &options{SearchStart: []string{"."}, Sort: "path", SortR: "size"}
+	a.Got(o.validateOptions()).Expect("could not use both --sort and --sortr").Match(t)
+
+	o = &options{SearchStart: []string{"."}, SortR: "foo"}
+	a.Got(o.validateOptions()).Expect("wrong sort key `foo`").Match(t)
+
+	o = &options{SearchStart: []string{"."}, Sort: "matches"}
+	a.Got(o.validateOptions()).NoError(t)
+}
+
+func TestValidateOptions_Mmap(t *testing.T) {
+	t.Parallel()
+	o := &options{SearchStart: []string{"."}, Mmap: true, NoMmap: true}
+	a.Got(o.validateOptions()).Expect("could not use both --mmap and --no-mmap").Match(t)
+
+	o = &options{SearchStart: []string{"."}, Mmap: true}
+	a.Got(o.validateOptions()).NoError(t)
 }
 
 func TestShowLangList(t *testing.T) {
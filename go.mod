@@ -24,7 +24,6 @@ require (
 	github.com/fatih/color v1.19.0
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20
-	github.com/monochromegane/go-gitignore v0.0.0-20200626010858-205db1a8cc00
 	golang.org/x/sync v0.21.0
 	golang.org/x/sys v0.47.0 // indirect
 )
@@ -0,0 +1,364 @@
+package xfgignore
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Rule is a compiled pattern of an ignore file
+type Rule struct {
+	Source  string // the path of the ignore file
+	Line    int
+	Pattern string // the pattern as written
+
+	negate   bool
+	dirOnly  bool
+	anchored bool      // has a slash, then it is matched against the path from the base directory
+	segments []segment // the pattern split by slash. An unanchored pattern has only one segment for the base name
+}
+
+type segment struct {
+	pattern string
+	literal bool // no glob meta
+	any     bool // `**`
+}
+
+// RuleSet is the rules of an ignore file. Patterns are relative to the base directory.
+// Rules are indexed by base names and extensions, to test only a few rules for each path.
+type RuleSet struct {
+	base   string
+	prefix string // the path from the directory of the ignore file to base, if the file is in an ancestor of base
+	rules  []*Rule
+	names  map[string][]int // unanchored literal patterns by the base name
+	exts   map[string][]int // unanchored patterns like `*.ext` by the extension
+	others []int            // other rules
+}
+
+// LoadRuleSet reads an ignore file. Patterns are relative to the base directory
+func LoadRuleSet(ignoreFile string, base string) (*RuleSet, error) {
+	fh, err := os.Open(ignoreFile)
+	if err != nil {
+		return nil, err
+	}
+	defer fh.Close()
+
+	return ParseRuleSet(fh, ignoreFile, base)
+}
+
+// LoadParentRuleSet reads an ignore file of the directory dir, which is an ancestor of the base directory.
+// Paths under base are matched as paths from dir.
+func LoadParentRuleSet(ignoreFile string, dir string, base string) (*RuleSet, error) {
+	prefix, err := relDir(dir, base)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := LoadRuleSet(ignoreFile, base)
+	if err != nil {
+		return nil, err
+	}
+	rs.prefix = prefix
+
+	return rs, nil
+}
+
+// relDir returns the slash-separated path from dir to base. It is empty if they are the same
+func relDir(dir string, base string) (string, error) {
+	absDir, err := realPath(dir)
+	if err != nil {
+		return "", err
+	}
+	absBase, err := realPath(base)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(absDir, absBase)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("`%s` is not under `%s`", base, dir)
+	}
+	if rel == "." {
+		return "", nil
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+func realPath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		return real, nil
+	}
+
+	return abs, nil
+}
+
+// ParseRuleSet parses rules of an ignore file. Invalid patterns are skipped like git does
+func ParseRuleSet(r io.Reader, source string, base string) (*RuleSet, error) {
+	rs := &RuleSet{
+		base:  filepath.Clean(base),
+		names: map[string][]int{},
+		exts:  map[string][]int{},
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lc := 1; scanner.Scan(); lc++ {
+		if rule := parseRule(scanner.Text()); rule != nil {
+			rule.Source = source
+			rule.Line = lc
+			rs.add(rule)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// parseRule returns nil for a blank line, a comment or an invalid pattern
+func parseRule(line string) *Rule {
+	line = trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	rule := &Rule{Pattern: line}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	rule.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return nil
+	}
+
+	for _, s := range strings.Split(line, "/") {
+		if _, err := path.Match(s, ""); err != nil {
+			return nil // bad pattern
+		}
+		rule.segments = append(rule.segments, segment{
+			pattern: s,
+			literal: !strings.ContainsAny(s, `*?[\`),
+			any:     s == "**",
+		})
+	}
+
+	return rule
+}
+
+// trimTrailingSpaces trims trailing spaces unless they are escaped with a backslash
+func trimTrailingSpaces(line string) string {
+	trimmed := strings.TrimRight(line, " ")
+	if trimmed != line && strings.HasSuffix(trimmed, `\`) {
+		return trimmed + " "
+	}
+
+	return trimmed
+}
+
+func (rs *RuleSet) add(rule *Rule) {
+	i := len(rs.rules)
+	rs.rules = append(rs.rules, rule)
+
+	if !rule.anchored {
+		s := rule.segments[0]
+		if s.literal {
+			rs.names[s.pattern] = append(rs.names[s.pattern], i)
+			return
+		}
+		if ext, ok := strings.CutPrefix(s.pattern, "*"); ok && ext != "" && ext == path.Ext(ext) && !strings.ContainsAny(ext, `*?[\`) {
+			rs.exts[ext] = append(rs.exts[ext], i)
+			return
+		}
+	}
+
+	rs.others = append(rs.others, i)
+}
+
+// Len returns the count of rules
+func (rs *RuleSet) Len() int {
+	if rs == nil {
+		return 0
+	}
+
+	return len(rs.rules)
+}
+
+// match returns the last rule which matches the path, or nil
+func (rs *RuleSet) match(rel string, name string, isDir bool) *Rule {
+	best := -1
+	for _, i := range rs.names[name] {
+		if i > best && rs.rules[i].matchDir(isDir) {
+			best = i
+		}
+	}
+
+	if ext := path.Ext(name); ext != "" {
+		for _, i := range rs.exts[ext] {
+			if i > best && rs.rules[i].matchDir(isDir) {
+				best = i
+			}
+		}
+	}
+
+	for j := len(rs.others) - 1; j >= 0 && rs.others[j] > best; j-- {
+		if rs.rules[rs.others[j]].match(rel, name, isDir) {
+			best = rs.others[j]
+			break
+		}
+	}
+
+	if best < 0 {
+		return nil
+	}
+
+	return rs.rules[best]
+}
+
+// Negate returns true if the rule re-includes paths with `!`
+func (r *Rule) Negate() bool {
+	return r.negate
+}
+
+func (r *Rule) matchDir(isDir bool) bool {
+	return !r.dirOnly || isDir
+}
+
+func (r *Rule) match(rel string, name string, isDir bool) bool {
+	if !r.matchDir(isDir) {
+		return false
+	}
+
+	if !r.anchored {
+		return matchSegment(r.segments[0], name)
+	}
+
+	return matchSegments(r.segments, strings.Split(rel, "/"))
+}
+
+func matchSegments(segments []segment, parts []string) bool {
+	for len(segments) > 0 {
+		if segments[0].any {
+			segments = segments[1:]
+			if len(segments) == 0 {
+				return len(parts) > 0 // `foo/**` matches all inside, but not `foo` itself
+			}
+			for i := range parts {
+				if matchSegments(segments, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 || !matchSegment(segments[0], parts[0]) {
+			return false
+		}
+		segments, parts = segments[1:], parts[1:]
+	}
+
+	return len(parts) == 0
+}
+
+func matchSegment(s segment, name string) bool {
+	if s.literal {
+		return s.pattern == name
+	}
+	ok, _ := path.Match(s.pattern, name)
+
+	return ok
+}
+
+// Matcher matches paths with rule sets from global ones to the current directory.
+// The last matched rule decides, and rule sets which were added later take precedence.
+type Matcher struct {
+	parent *Matcher
+	set    *RuleSet
+}
+
+// NewMatcher makes a matcher of rule sets in order of precedence, from the lowest
+func NewMatcher(sets ...*RuleSet) *Matcher {
+	var m *Matcher
+	for _, set := range sets {
+		m = m.With(set)
+	}
+
+	return m
+}
+
+// With returns a matcher which gives the rule set precedence over rule sets of m. m is not changed
+func (m *Matcher) With(set *RuleSet) *Matcher {
+	if set.Len() == 0 {
+		return m
+	}
+
+	return &Matcher{parent: m, set: set}
+}
+
+// Match returns true if the path is ignored
+func (m *Matcher) Match(p string, isDir bool) bool {
+	r := m.Explain(p, isDir)
+
+	return r != nil && !r.negate
+}
+
+// Explain returns the rule which decides whether the path is ignored or not, or nil
+func (m *Matcher) Explain(p string, isDir bool) *Rule {
+	name := filepath.Base(p)
+	for c := m; c != nil; c = c.parent {
+		rel, ok := relPath(c.set.base, p)
+		if !ok {
+			continue // out of the base directory
+		}
+		if c.set.prefix != "" {
+			rel = c.set.prefix + "/" + rel
+		}
+		if r := c.set.match(rel, name, isDir); r != nil {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// relPath returns the slash-separated path from the base directory. false if the path is out of base
+func relPath(base string, p string) (string, bool) {
+	if base == "." && !filepath.IsAbs(p) {
+		p = filepath.Clean(p)
+		if p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		return filepath.ToSlash(p), true
+	}
+
+	if rest, ok := strings.CutPrefix(p, base); ok && len(rest) > 1 && os.IsPathSeparator(rest[0]) {
+		return filepath.ToSlash(rest[1:]), true
+	}
+
+	rel, err := filepath.Rel(base, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,157 @@
+package xfgignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	here "github.com/MakeNowJust/heredoc/v2"
+	a "github.com/bayashi/actually"
+)
+
+func ruleSet(t *testing.T, base string, content string) *RuleSet {
+	t.Helper()
+	rs, err := ParseRuleSet(strings.NewReader(content), base+"/.gitignore", base)
+	a.Got(err).NoError(t)
+
+	return rs
+}
+
+func TestRuleSet_Match(t *testing.T) {
+	t.Parallel()
+	rs := ruleSet(t, "root", here.Doc(`
+		# comment
+		*.log
+		!keep.log
+		node_modules
+		/build
+		docs/*.md
+		**/tmp/**
+		a/**/z
+		\#hash
+		trailing\ 
+		dironly/
+		[
+	`))
+	a.Got(rs.Len()).Expect(10).Same(t)
+
+	for _, tt := range []struct {
+		path   string
+		isDir  bool
+		expect bool
+	}{
+		{path: "root/x.log", expect: true},
+		{path: "root/sub/x.log", expect: true},
+		{path: "root/keep.log", expect: false},
+		{path: "root/x.logs", expect: false},
+		{path: "root/sub/node_modules", expect: true},
+		{path: "root/build", expect: true},
+		{path: "root/sub/build", expect: false},
+		{path: "root/docs/a.md", expect: true},
+		{path: "root/sub/docs/a.md", expect: false},
+		{path: "root/docs/sub/a.md", expect: false},
+		{path: "root/x/tmp/y", expect: true},
+		{path: "root/tmp", expect: false},
+		{path: "root/a/z", expect: true},
+		{path: "root/a/b/c/z", expect: true},
+		{path: "root/#hash", expect: true},
+		{path: "root/trailing ", expect: true},
+		{path: "root/dironly", expect: false},
+		{path: "root/dironly", isDir: true, expect: true},
+		{path: "other/x.log", expect: false},
+	} {
+		a.Got(NewMatcher(rs).Match(tt.path, tt.isDir)).Expect(tt.expect).X().Debug("path", tt.path).Same(t)
+	}
+}
+
+func TestMatcher_Precedence(t *testing.T) {
+	t.Parallel()
+	global := ruleSet(t, "root", "*.txt\n")
+	m := NewMatcher(global)
+	sub := m.With(ruleSet(t, "root/sub", "!keep.txt\n/local\n"))
+
+	a.Got(m.Match("root/sub/keep.txt", false)).True(t)
+	a.Got(sub.Match("root/sub/keep.txt", false)).False(t)
+	a.Got(sub.Match("root/sub/other.txt", false)).True(t)
+	a.Got(sub.Match("root/keep.txt", false)).True(t)
+	a.Got(sub.Match("root/sub/local", false)).True(t)
+	a.Got(sub.Match("root/local", false)).False(t)
+
+	r := sub.Explain("root/sub/keep.txt", false)
+	a.Got(r.Source).Expect("root/sub/.gitignore").Same(t)
+	a.Got(r.Line).Expect(1).Same(t)
+	a.Got(r.Negate()).True(t)
+
+	a.Got(NewMatcher().Match("root/a.txt", false)).False(t)
+}
+
+func TestRelPath(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		base     string
+		path     string
+		expect   string
+		expectOK bool
+	}{
+		{base: ".", path: "a/b", expect: "a/b", expectOK: true},
+		{base: ".", path: "./a/b", expect: "a/b", expectOK: true},
+		{base: ".", path: "../a", expectOK: false},
+		{base: "root", path: "root/a/b", expect: "a/b", expectOK: true},
+		{base: "root", path: "rootx/a", expectOK: false},
+		{base: "/", path: "/a/b", expect: "a/b", expectOK: true},
+		{base: "./root", path: "root/a", expect: "a", expectOK: true},
+	} {
+		rel, ok := relPath(filepath.Clean(tt.base), tt.path)
+		a.Got(ok).Expect(tt.expectOK).X().Debug("base", tt.base).Debug("path", tt.path).Same(t)
+		a.Got(rel).Expect(tt.expect).X().Debug("base", tt.base).Debug("path", tt.path).Same(t)
+	}
+}
+
+func TestLoadParentRuleSet(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	base := filepath.Join(repo, "a", "b")
+	a.Got(os.MkdirAll(base, 0755)).NoError(t)
+	ignoreFile := filepath.Join(repo, GITIGNORE_FILE_NAME)
+	a.Got(os.WriteFile(ignoreFile, []byte("/a/b/top\n/top2\na/b/c/*.txt\n"), 0644)).NoError(t)
+
+	rs, err := LoadParentRuleSet(ignoreFile, repo, base)
+	a.Got(err).NoError(t)
+	m := NewMatcher(rs)
+	a.Got(m.Match(filepath.Join(base, "top"), false)).True(t)
+	a.Got(m.Match(filepath.Join(base, "top2"), false)).False(t)
+	a.Got(m.Match(filepath.Join(base, "c", "d.txt"), false)).True(t)
+	a.Got(m.Match(filepath.Join(base, "d.txt"), false)).False(t)
+
+	_, err = LoadParentRuleSet(ignoreFile, base, repo)
+	a.Got(err).NotNil(t)
+}
+
+func TestParentDirs(t *testing.T) {
+	t.Parallel()
+	repo := t.TempDir()
+	dir := filepath.Join(repo, "a", "b")
+	a.Got(os.MkdirAll(dir, 0755)).NoError(t)
+
+	a.Got(parentDirs(repo, dir)).Expect([]string{repo, filepath.Join(repo, "a")}).Same(t)
+	a.Got(parentDirs(repo, repo)).Nil(t)
+}
+
+func BenchmarkMatcher_Match(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		sb.WriteString("name" + string(rune('a'+i%26)) + "\n*.ext" + string(rune('a'+i%26)) + "\n")
+	}
+	sb.WriteString("/anchored/*.go\n")
+	rs, _ := ParseRuleSet(strings.NewReader(sb.String()), ".gitignore", "root")
+	m := NewMatcher(rs)
+	for i := 0; i < 10; i++ {
+		m = m.With(rs)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match("root/a/b/c/file.go", false)
+	}
+}
@@ -0,0 +1,231 @@
+package xfgignore
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+)
+
+const DOT_GIT = ".git"
+
+// Repository is a git repository which is found without git command
+type Repository struct {
+	Root      string // the top directory of the working tree
+	GitDir    string // $GIT_DIR. It is in the main repository for a linked worktree or a submodule
+	CommonDir string // the directory which has `config` and `info`. Same as GitDir except for a linked worktree
+}
+
+// FindRepository walks up from the directory to find `.git`. It returns nil out of repositories
+func FindRepository(dir string) *Repository {
+	d, err := realPath(dir)
+	if err != nil {
+		return nil
+	}
+
+	for {
+		if gitDir := resolveGitDir(filepath.Join(d, DOT_GIT)); gitDir != "" {
+			return &Repository{
+				Root:      d,
+				GitDir:    gitDir,
+				CommonDir: resolveCommonDir(gitDir),
+			}
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return nil
+		}
+		d = parent
+	}
+}
+
+// resolveGitDir returns $GIT_DIR for `.git`, which is a directory or a file like `gitdir: ../.git/worktrees/foo`
+func resolveGitDir(dotGit string) string {
+	fi, err := os.Stat(dotGit)
+	if err != nil {
+		return ""
+	}
+
+	if fi.IsDir() {
+		if !isGitDir(dotGit) {
+			return ""
+		}
+		return dotGit
+	}
+
+	b, err := os.ReadFile(dotGit)
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+	if !isGitDir(gitDir) {
+		return ""
+	}
+
+	return filepath.Clean(gitDir)
+}
+
+// isGitDir returns true if the directory looks like $GIT_DIR
+func isGitDir(dir string) bool {
+	fi, err := os.Stat(filepath.Join(dir, "HEAD"))
+
+	return err == nil && !fi.IsDir()
+}
+
+// resolveCommonDir reads `commondir` of a linked worktree. It is relative to $GIT_DIR
+func resolveCommonDir(gitDir string) string {
+	b, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	commonDir := strings.TrimSpace(string(b))
+	if commonDir == "" {
+		return gitDir
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+
+	return filepath.Clean(commonDir)
+}
+
+// ExcludePath returns the path of `info/exclude`, e.g. $GIT_DIR/info/exclude
+func (r *Repository) ExcludePath() string {
+	return filepath.Join(r.CommonDir, "info", "exclude")
+}
+
+// ConfigPath returns the path of the config file of the repository
+func (r *Repository) ConfigPath() string {
+	return filepath.Join(r.CommonDir, "config")
+}
+
+// e.g. $XDG_CONFIG_HOME/git/config
+func globalXDGGitConfigPath() string {
+	return filepath.Join(xdg.ConfigHome, GIT, "config")
+}
+
+// e.g. $HOME/.gitconfig
+func globalHomeDirGitConfigPath(homeDir string) string {
+	return filepath.Join(homeDir, ".gitconfig")
+}
+
+// excludesFilePath returns the path of `core.excludesFile` in global configs and the repository config.
+// The last one wins like git does. It returns "" if it is not set.
+func excludesFilePath(repo *Repository, homeDir string) string {
+	configs := []string{globalXDGGitConfigPath()}
+	if homeDir != "" {
+		configs = append(configs, globalHomeDirGitConfigPath(homeDir))
+	}
+	if repo != nil {
+		configs = append(configs, repo.ConfigPath())
+	}
+
+	excludesFile := ""
+	for _, config := range configs {
+		if v, ok := readGitConfig(config, "core", "excludesfile"); ok {
+			excludesFile = v
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(excludesFile, "~/"); ok && homeDir != "" {
+		return filepath.Join(homeDir, rest)
+	}
+
+	return excludesFile
+}
+
+// readGitConfig returns the last value of the key in the section of a git config file.
+// Section and key names are case-insensitive. Subsections and `include` are not supported.
+func readGitConfig(configFile string, section string, key string) (string, bool) {
+	fh, err := os.Open(configFile)
+	if err != nil {
+		return "", false
+	}
+	defer fh.Close()
+
+	value, found := "", false
+	current := ""
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				current = ""
+				continue
+			}
+			current = strings.ToLower(strings.TrimSpace(line[1:end]))
+			line = strings.TrimSpace(line[end+1:]) // a key can follow the header on the same line
+			if line == "" {
+				continue
+			}
+		}
+
+		if current != section {
+			continue
+		}
+
+		name, rest, hasValue := strings.Cut(line, "=")
+		if !strings.EqualFold(strings.TrimSpace(name), key) {
+			continue
+		}
+		value, found = "true", true // a key without value is a boolean true
+		if hasValue {
+			value = parseGitConfigValue(rest)
+		}
+	}
+
+	return value, found
+}
+
+// parseGitConfigValue unquotes a value, and removes comments and surrounding spaces
+func parseGitConfigValue(raw string) string {
+	var sb strings.Builder
+	inQuote := false
+	pendingSpaces := ""
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c == '\\' && i+1 < len(raw):
+			i++
+			switch raw[i] {
+			case 'n':
+				c = '\n'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			default:
+				c = raw[i]
+			}
+		case c == '"':
+			inQuote = !inQuote
+			continue
+		case !inQuote && (c == '#' || c == ';'):
+			return sb.String()
+		case !inQuote && (c == ' ' || c == '\t'):
+			if sb.Len() > 0 {
+				pendingSpaces += string(c)
+			}
+			continue
+		}
+		sb.WriteString(pendingSpaces)
+		pendingSpaces = ""
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
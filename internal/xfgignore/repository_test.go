@@ -0,0 +1,164 @@
+package xfgignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/adrg/xdg"
+	a "github.com/bayashi/actually"
+)
+
+func writeFile(t *testing.T, p string, content string) {
+	t.Helper()
+	a.Got(os.MkdirAll(filepath.Dir(p), 0755)).NoError(t)
+	a.Got(os.WriteFile(p, []byte(content), 0644)).NoError(t)
+}
+
+func realTempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := realPath(t.TempDir())
+	a.Got(err).NoError(t)
+
+	return dir
+}
+
+func TestFindRepository(t *testing.T) {
+	t.Parallel()
+	root := realTempDir(t)
+	repo := filepath.Join(root, "repo")
+	writeFile(t, filepath.Join(repo, DOT_GIT, "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(repo, "a", "b", "c.txt"), "")
+
+	r := FindRepository(filepath.Join(repo, "a", "b"))
+	a.Got(r).NotNil(t)
+	a.Got(r.Root).Expect(repo).Same(t)
+	a.Got(r.GitDir).Expect(filepath.Join(repo, DOT_GIT)).Same(t)
+	a.Got(r.ExcludePath()).Expect(filepath.Join(repo, DOT_GIT, "info", "exclude")).Same(t)
+
+	a.Got(FindRepository(root)).Nil(t)
+
+	// `.git` without HEAD is not a repository
+	writeFile(t, filepath.Join(root, "fake", DOT_GIT, "config"), "")
+	a.Got(FindRepository(filepath.Join(root, "fake"))).Nil(t)
+}
+
+func TestFindRepository_Worktree(t *testing.T) {
+	t.Parallel()
+	root := realTempDir(t)
+	main := filepath.Join(root, "main")
+	gitDir := filepath.Join(main, DOT_GIT, "worktrees", "wt")
+	writeFile(t, filepath.Join(main, DOT_GIT, "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(gitDir, "HEAD"), "ref: refs/heads/wt\n")
+	writeFile(t, filepath.Join(gitDir, "commondir"), "../..\n")
+	wt := filepath.Join(root, "wt")
+	writeFile(t, filepath.Join(wt, DOT_GIT), "gitdir: "+gitDir+"\n")
+
+	r := FindRepository(wt)
+	a.Got(r).NotNil(t)
+	a.Got(r.Root).Expect(wt).Same(t)
+	a.Got(r.GitDir).Expect(gitDir).Same(t)
+	a.Got(r.CommonDir).Expect(filepath.Join(main, DOT_GIT)).Same(t)
+	a.Got(r.ExcludePath()).Expect(filepath.Join(main, DOT_GIT, "info", "exclude")).Same(t)
+
+	// relative gitdir like a submodule
+	sub := filepath.Join(main, "sub")
+	writeFile(t, filepath.Join(main, DOT_GIT, "modules", "sub", "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(sub, DOT_GIT), "gitdir: ../.git/modules/sub\n")
+
+	r = FindRepository(sub)
+	a.Got(r).NotNil(t)
+	a.Got(r.Root).Expect(sub).Same(t)
+	a.Got(r.CommonDir).Expect(filepath.Join(main, DOT_GIT, "modules", "sub")).Same(t)
+}
+
+func TestReadGitConfig(t *testing.T) {
+	t.Parallel()
+	config := filepath.Join(t.TempDir(), "config")
+	writeFile(t, config, `# comment
+[user]
+	excludesfile = /not/core
+[Core]
+	bare = false
+	ExcludesFile = /first
+[core "sub"]
+	excludesFile = /subsection
+[core]
+	excludesFile = "/path/with space" ; comment
+`)
+
+	v, ok := readGitConfig(config, "core", "excludesfile")
+	a.Got(ok).True(t)
+	a.Got(v).Expect("/path/with space").Same(t)
+
+	_, ok = readGitConfig(config, "core", "nothing")
+	a.Got(ok).False(t)
+
+	_, ok = readGitConfig(filepath.Join(t.TempDir(), "none"), "core", "excludesfile")
+	a.Got(ok).False(t)
+}
+
+func TestParseGitConfigValue(t *testing.T) {
+	t.Parallel()
+	for raw, expect := range map[string]string{
+		" foo ":            "foo",
+		" foo  bar # c":    "foo  bar",
+		` "a ; b" `:        "a ; b",
+		` a\"b`:            `a"b`,
+		` C:\\Users\\foo `: `C:\Users\foo`,
+		"":                 "",
+	} {
+		a.Got(parseGitConfigValue(raw)).Expect(expect).X().Debug("raw", raw).Same(t)
+	}
+}
+
+func TestSetUpGlobalGitIgnores_Repository(t *testing.T) {
+	t.Parallel()
+	root := realTempDir(t)
+	home := filepath.Join(root, "home")
+	repo := filepath.Join(root, "repo")
+	writeFile(t, filepath.Join(repo, DOT_GIT, "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(repo, DOT_GIT, "info", "exclude"), "/a/excluded\n")
+	writeFile(t, filepath.Join(repo, DOT_GIT, "config"), "[core]\n\texcludesFile = ~/my-ignore\n")
+	writeFile(t, filepath.Join(home, "my-ignore"), "*.global\n")
+	writeFile(t, filepath.Join(repo, GITIGNORE_FILE_NAME), "/a/top\n")
+	start := filepath.Join(repo, "a")
+	a.Got(os.MkdirAll(start, 0755)).NoError(t)
+
+	m := NewMatcher(SetUpGlobalGitIgnores(start, home)...)
+	a.Got(m.Match(filepath.Join(start, "excluded"), false)).True(t)
+	a.Got(m.Match(filepath.Join(start, "top"), false)).True(t)
+	a.Got(m.Match(filepath.Join(start, "b", "c.global"), false)).True(t)
+	a.Got(m.Match(filepath.Join(start, "other"), false)).False(t)
+}
+
+func TestSetUpGlobalGitIgnores_ExcludesFile(t *testing.T) {
+	root := realTempDir(t)
+	home := filepath.Join(root, "home")
+	configHome := filepath.Join(root, "config")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	repo := filepath.Join(root, "repo")
+	writeFile(t, filepath.Join(repo, DOT_GIT, "HEAD"), "ref: refs/heads/main\n")
+	writeFile(t, filepath.Join(home, ".config", GIT, "ignore"), "*.home\n")
+	writeFile(t, filepath.Join(home, "my-ignore"), "*.global\n")
+
+	match := func(name string) bool {
+		return NewMatcher(SetUpGlobalGitIgnores(repo, home)...).Match(filepath.Join(repo, name), false)
+	}
+
+	// $HOME/.config/git/ignore without $XDG_CONFIG_HOME/git/ignore
+	a.Got(match("a.home")).True(t)
+
+	writeFile(t, filepath.Join(configHome, GIT, "ignore"), "*.xdg\n")
+	a.Got(match("a.xdg")).True(t)
+	a.Got(match("a.home")).False(t)
+
+	writeFile(t, filepath.Join(configHome, GIT, "config"), "[core]\n\texcludesFile = ~/my-ignore\n")
+	a.Got(match("a.global")).True(t)
+	a.Got(match("a.xdg")).False(t)
+	a.Got(match("a.home")).False(t)
+}
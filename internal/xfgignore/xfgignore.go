@@ -1,21 +1,23 @@
 package xfgignore
 
 import (
-	"os/exec"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/adrg/xdg"
-	"github.com/monochromegane/go-gitignore"
 )
 
 const (
 	GIT                 = "git"
 	GITIGNORE_FILE_NAME = ".gitignore"
 	XFGIGNORE_FILE_NAME = ".xfgignore"
-)
 
-type Matchers []gitignore.IgnoreMatcher
+	// ignore files of other tools
+	DOT_IGNORE_FILE_NAME = ".ignore"
+	RGIGNORE_FILE_NAME   = ".rgignore"
+	FDIGNORE_FILE_NAME   = ".fdignore"
+)
 
 // https://git-scm.com/docs/gitignore
 
@@ -39,59 +41,21 @@ func globalHomeDirGitignorePath(homeDir string) string {
 	return filepath.Join(homeDir, GITIGNORE_FILE_NAME)
 }
 
-// e.g. $REPOSITORY_ROOT/.gitignore
-func repoRootDirGitignorePath(rootDir string) string {
-	gitCmd, err := exec.LookPath(GIT)
-	if err != nil {
-		return "" // trap error, probably command not found
-	}
-	repoRoot, err := exec.Command(gitCmd, "rev-parse", "--show-toplevel").Output()
-	if err != nil {
-		return "" // trap error
-	}
-	absRepoRoot, err := filepath.Abs(string(repoRoot))
-	if err != nil {
-		return "" // trap error
-	}
-
-	absRootDir, err := filepath.Abs(rootDir)
-	if err != nil {
-		return "" // trap error
-	}
-
-	if absRootDir == absRepoRoot {
-		return "" // no need to read here. read this later. On each dir.
+// parentDirs returns directories from the root directory of the repository to the parent of the directory.
+// e.g. $REPOSITORY_ROOT, $REPOSITORY_ROOT/foo for $REPOSITORY_ROOT/foo/bar
+func parentDirs(repoRoot string, dir string) []string {
+	prefix, err := relDir(repoRoot, dir)
+	if err != nil || prefix == "" {
+		return nil
 	}
 
-	return filepath.Join(absRepoRoot, GITIGNORE_FILE_NAME)
-}
-
-// e.g. $REPOSITORY_ROOT/info/exclude
-func userGitignorePath() string {
-	gitCmd, err := exec.LookPath(GIT)
-	if err != nil {
-		return "" // trap error, probably command not found
-	}
-	repoRoot, err := exec.Command(gitCmd, "rev-parse", "--show-toplevel").Output()
-	if err != nil {
-		return "" // trap error
+	dirs := []string{repoRoot}
+	elements := strings.Split(prefix, "/")
+	for _, e := range elements[:len(elements)-1] {
+		dirs = append(dirs, filepath.Join(dirs[len(dirs)-1], e))
 	}
 
-	return filepath.Join(string(repoRoot), "info", "exclude")
-}
-
-// User specifies path
-func userConfigGitignorePath() string {
-	gitCmd, err := exec.LookPath(GIT)
-	if err != nil {
-		return "" // trap error, probably command not found
-	}
-	ret, err := exec.Command(gitCmd, "config", "--get", "core.excludesfile").Output()
-	if err != nil {
-		return "" // trap error
-	}
-
-	return strings.TrimSpace(string(ret))
+	return dirs
 }
 
 // e.g. $XDG_CONFIG_HOME/xfg/.xfgignore
@@ -104,43 +68,75 @@ func userHomeDirXFGignorePath(homeDir string) string {
 	return filepath.Join(homeDir, XFGIGNORE_FILE_NAME)
 }
 
-func SetUpGlobalGitIgnores(rootDirPath string, homeDir string) Matchers {
-	var ms Matchers
-	if matcher, err := gitignore.NewGitIgnore(globalXDGGitignorePath(), rootDirPath); err == nil {
-		ms = append(ms, matcher)
-	} else if matcher, err := gitignore.NewGitIgnore(globalHomeGitignorePath(homeDir), rootDirPath); err == nil {
-		ms = append(ms, matcher)
+// SetUpGlobalGitIgnores returns rule sets of git outside of the start directory, in order of precedence from the lowest.
+// Patterns of them are relative to the root directory of the repository, or to the start directory out of repositories.
+// A `.gitignore` of the start directory is not included. It is read on walking.
+// The repository is found by walking up from the start directory, without git command.
+func SetUpGlobalGitIgnores(rootDirPath string, homeDir string) []*RuleSet {
+	repo := FindRepository(rootDirPath)
+	repoRoot := ""
+	if repo != nil {
+		repoRoot = repo.Root
+	}
+
+	var sets []*RuleSet
+	loaded := map[string]bool{}
+	load := func(ignoreFile string, dir string) {
+		if ignoreFile == "" || loaded[ignoreFile] {
+			return
+		}
+		loaded[ignoreFile] = true
+		var set *RuleSet
+		var err error
+		if dir == "" {
+			set, err = LoadRuleSet(ignoreFile, rootDirPath)
+		} else {
+			set, err = LoadParentRuleSet(ignoreFile, dir, rootDirPath)
+		}
+		if err == nil {
+			sets = append(sets, set)
+		}
 	}
 
+	// `git/ignore` is the default of core.excludesFile. It is not read if core.excludesFile is set
+	excludesFile := excludesFilePath(repo, homeDir)
+	if excludesFile == "" {
+		if _, err := os.Stat(globalXDGGitignorePath()); err == nil {
+			excludesFile = globalXDGGitignorePath()
+		} else {
+			excludesFile = globalHomeGitignorePath(homeDir)
+		}
+	}
+	load(excludesFile, repoRoot)
+
 	for _, gitignorePath := range []string{
 		globalObsoleteHomeDirGitignorePath(homeDir),
 		globalHomeDirGitignorePath(homeDir),
-		repoRootDirGitignorePath(rootDirPath),
-		userGitignorePath(),
-		userConfigGitignorePath(),
 	} {
-		if gitignorePath == "" {
-			continue
-		}
-		if matcher, err := gitignore.NewGitIgnore(gitignorePath, rootDirPath); err == nil {
-			ms = append(ms, matcher)
+		load(gitignorePath, repoRoot)
+	}
+
+	if repo != nil {
+		load(repo.ExcludePath(), repoRoot)
+		for _, dir := range parentDirs(repoRoot, rootDirPath) {
+			load(filepath.Join(dir, GITIGNORE_FILE_NAME), dir)
 		}
 	}
 
-	return ms
+	return sets
 }
 
-func SetupGlobalXFGIgnore(rootDirPath string, homeDir string, xfgignore string) Matchers {
-	var ms Matchers
+func SetupGlobalXFGIgnore(rootDirPath string, homeDir string, xfgignore string) []*RuleSet {
+	var sets []*RuleSet
 	for _, xfgignorePath := range []string{
 		userXDGXFGignorePath(),
 		userHomeDirXFGignorePath(homeDir),
 		xfgignore,
 	} {
-		if matcher, err := gitignore.NewGitIgnore(xfgignorePath, rootDirPath); err == nil {
-			ms = append(ms, matcher)
+		if set, err := LoadRuleSet(xfgignorePath, rootDirPath); err == nil {
+			sets = append(sets, set)
 		}
 	}
 
-	return ms
+	return sets
 }
@@ -7,9 +7,9 @@ import (
 )
 
 func TestSetUpGlobalGitIgnores(t *testing.T) {
-	a.Got(SetUpGlobalGitIgnores("", "")).Expect(Matchers{}).SameType(t)
+	a.Got(SetUpGlobalGitIgnores("", "")).Expect([]*RuleSet{}).SameType(t)
 }
 
 func TestSetupGlobalXFGIgnore(t *testing.T) {
-	a.Got(SetupGlobalXFGIgnore("", "", "")).Expect(Matchers{}).SameType(t)
+	a.Got(SetupGlobalXFGIgnore("", "", "")).Expect([]*RuleSet{}).SameType(t)
 }
@@ -19,18 +19,35 @@ type count struct {
 	walkedPaths    int
 	walkedContents int
 	scannedFile    int
+	mappedFile     int // files which were mapped into memory to scan
+	readFile       int // files which were read to scan
 	pickedPaths    int
 	pickedLC       int
 	outputLC       int
 	scannedLC      int
+	skippedBySize  int
+	ignoreFiles    int
+	ignoreEval     int
+}
+
+// Pool is the utilisation of a worker pool
+type Pool struct {
+	Workers int
+	Tasks   int
+	Peak    int           // the max count of tasks which ran at the same time
+	Inline  int           // the count of tasks which ran in the caller goroutine
+	Wait    time.Duration // the time to wait for a free worker
 }
 
 type Stats struct {
-	mu    sync.RWMutex
-	procs int
-	start time.Time
-	lap   []lap
-	count count
+	mu       sync.RWMutex
+	procs    int
+	start    time.Time
+	lap      []lap
+	count    count
+	walkPool Pool
+	scanPool Pool
+	ignore   time.Duration // the total time of all workers to evaluate ignore rules
 }
 
 func New(procs int) *Stats {
@@ -55,7 +72,11 @@ func (s *Stats) Show(out io.Writer) {
 	}
 	result = result + fmt.Sprintf("[Env]\n procs: %d\n", s.procs)
 	result = result + fmt.Sprintf("[Walk]\n paths: %d\n contents: %d\n", s.count.walkedPaths, s.count.walkedContents)
-	result = result + fmt.Sprintf("[Scanned]\n files: %d\n lines: %d\n", s.count.scannedFile, s.count.scannedLC)
+	result = result + fmt.Sprintf("[Scanned]\n files: %d\n  mmap: %d\n  read: %d\n lines: %d\n", s.count.scannedFile, s.count.mappedFile, s.count.readFile, s.count.scannedLC)
+	result = result + fmt.Sprintf("[Skipped]\n by size: %d\n", s.count.skippedBySize)
+	result = result + fmt.Sprintf("[Ignore]\n files: %d\n evaluated: %d\n time: %s\n", s.count.ignoreFiles, s.count.ignoreEval, s.ignore.String())
+	result = result + fmt.Sprintf("[Pool]\n walk: workers %d, tasks %d, peak %d, inline %d\n", s.walkPool.Workers, s.walkPool.Tasks, s.walkPool.Peak, s.walkPool.Inline)
+	result = result + fmt.Sprintf(" scan: workers %d, tasks %d, peak %d, wait %s\n", s.scanPool.Workers, s.scanPool.Tasks, s.scanPool.Peak, s.scanPool.Wait.String())
 	result = result + fmt.Sprintf("[Result]\n picked paths: %d\n picked lc: %d\n output lc: %d\n", s.count.pickedPaths, s.count.pickedLC, s.count.outputLC)
 
 	xfgutil.Output(bufio.NewWriter(out), result)
@@ -79,12 +100,48 @@ func (s *Stats) IncrScannedFile() {
 	s.mu.Unlock()
 }
 
+func (s *Stats) IncrMappedFile() {
+	s.mu.Lock()
+	s.count.mappedFile++
+	s.mu.Unlock()
+}
+
+func (s *Stats) IncrReadFile() {
+	s.mu.Lock()
+	s.count.readFile++
+	s.mu.Unlock()
+}
+
+func (s *Stats) IncrSkippedBySize() {
+	s.mu.Lock()
+	s.count.skippedBySize++
+	s.mu.Unlock()
+}
+
+func (s *Stats) IncrIgnoreFile() {
+	s.mu.Lock()
+	s.count.ignoreFiles++
+	s.mu.Unlock()
+}
+
+func (s *Stats) AddIgnoreEval(t time.Duration) {
+	s.mu.Lock()
+	s.count.ignoreEval++
+	s.ignore = s.ignore + t
+	s.mu.Unlock()
+}
+
 func (s *Stats) IncrScannedLC(count int) {
 	s.mu.Lock()
 	s.count.scannedLC = s.count.scannedLC + count
 	s.mu.Unlock()
 }
 
+func (s *Stats) SetPool(walk Pool, scan Pool) {
+	s.walkPool = walk
+	s.scanPool = scan
+}
+
 func (s *Stats) SetPickedPaths(count int) {
 	s.count.pickedPaths = count
 }
@@ -24,7 +24,17 @@ func TestStats(t *testing.T) {
 	a.Got(o.String()).Expect(`contents:\s+\d+\n`).Match(t)
 	a.Got(o.String()).Expect(`\[Scanned\]\n`).Match(t)
 	a.Got(o.String()).Expect(`files:\s+\d+\n`).Match(t)
+	a.Got(o.String()).Expect(`mmap:\s+\d+\n`).Match(t)
+	a.Got(o.String()).Expect(`read:\s+\d+\n`).Match(t)
 	a.Got(o.String()).Expect(`lines:\s+\d+\n`).Match(t)
+	a.Got(o.String()).Expect(`\[Skipped\]\n`).Match(t)
+	a.Got(o.String()).Expect(`by size:\s+\d+\n`).Match(t)
+	a.Got(o.String()).Expect(`\[Ignore\]\n`).Match(t)
+	a.Got(o.String()).Expect(`evaluated:\s+\d+\n`).Match(t)
+	a.Got(o.String()).Expect(`time:\s+.+\n`).Match(t)
+	a.Got(o.String()).Expect(`\[Pool\]\n`).Match(t)
+	a.Got(o.String()).Expect(`walk: workers \d+, tasks \d+, peak \d+, inline \d+\n`).Match(t)
+	a.Got(o.String()).Expect(`scan: workers \d+, tasks \d+, peak \d+, wait .+\n`).Match(t)
 	a.Got(o.String()).Expect(`\[Result\]\n`).Match(t)
 	a.Got(o.String()).Expect(`picked paths:\s+\d+\n`).Match(t)
 	a.Got(o.String()).Expect(`output lc:\s+\d\n`).Match(t)
@@ -0,0 +1,18 @@
+//go:build unix
+
+package xfgutil
+
+import (
+	"os"
+	"syscall"
+)
+
+// Mmap maps the file into memory to read. size must be more than 0
+func Mmap(fh *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(fh.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// Munmap unmaps the data which was mapped by Mmap
+func Munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package xfgutil
+
+import (
+	"errors"
+	"os"
+)
+
+var errMmapNotSupported = errors.New("mmap is not supported on this platform")
+
+// Mmap is not available on Windows, plan9 and js. Files are read instead
+func Mmap(fh *os.File, size int) ([]byte, error) {
+	return nil, errMmapNotSupported
+}
+
+// Munmap is not available on Windows, plan9 and js
+func Munmap(data []byte) error {
+	return errMmapNotSupported
+}
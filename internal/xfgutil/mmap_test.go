@@ -0,0 +1,26 @@
+//go:build unix
+
+package xfgutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestMmap(t *testing.T) {
+	t.Parallel()
+	fPath := filepath.Join(t.TempDir(), "file")
+	a.Got(os.WriteFile(fPath, []byte("foo\nbar\n"), 0644)).NoError(t)
+
+	fh, err := os.Open(fPath)
+	a.Got(err).NoError(t)
+	defer fh.Close()
+
+	data, err := Mmap(fh, 8)
+	a.Got(err).NoError(t)
+	a.Got(string(data)).Expect("foo\nbar\n").Same(t)
+	a.Got(Munmap(data)).NoError(t)
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package xfgutil
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// DeviceID returns the ID of the device which has the file. false if it is not available
+func DeviceID(fi fs.FileInfo) (uint64, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+
+	return uint64(st.Dev), true // the type of Dev differs by platform
+}
+
+// Owner returns user ID and group ID of the file. false if it is not available
+func Owner(fi fs.FileInfo) (uint32, uint32, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return st.Uid, st.Gid, true
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package xfgutil
+
+import "io/fs"
+
+// DeviceID is not available on Windows, plan9 and js
+func DeviceID(fi fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// Owner is not available on Windows, plan9 and js
+func Owner(fi fs.FileInfo) (uint32, uint32, bool) {
+	return 0, 0, false
+}
@@ -27,6 +27,22 @@ func IsTTY() bool {
 	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
 }
 
+func IsPipedStdin() bool {
+	return isPipedFile(os.Stdin)
+}
+
+func isPipedFile(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	mode := fi.Mode()
+
+	// a redirected regular file is not piped. It is common on cron and CI
+	return mode&os.ModeNamedPipe != 0 || mode&os.ModeSocket != 0
+}
+
 func HomeDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -1,6 +1,9 @@
 package xfgutil
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
 	a "github.com/bayashi/actually"
@@ -17,9 +20,62 @@ func TestIsTTY(t *testing.T) {
 	a.Got(IsTTY()).False(t)
 }
 
+func TestIsPipedFile(t *testing.T) {
+	t.Parallel()
+	r, w, err := os.Pipe()
+	a.Got(err).NoError(t)
+	defer r.Close()
+	defer w.Close()
+	a.Got(isPipedFile(r)).True(t)
+
+	dir, err := os.Open(t.TempDir())
+	a.Got(err).NoError(t)
+	defer dir.Close()
+	a.Got(isPipedFile(dir)).False(t)
+
+	file, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	a.Got(err).NoError(t)
+	defer file.Close()
+	a.Got(isPipedFile(file)).False(t)
+
+	closed, err := os.Open(t.TempDir())
+	a.Got(err).NoError(t)
+	closed.Close()
+	a.Got(isPipedFile(closed)).False(t)
+}
+
 func TestHomeDir(t *testing.T) {
 	t.Parallel()
 	homeDir, err := HomeDir()
 	a.Got(err).NoError(t)
 	a.Got(homeDir).Expect("").NotSame(t)
 }
+
+// hasStat returns true on the platforms which are built with stat.go
+func hasStat() bool {
+	switch runtime.GOOS {
+	case "windows", "plan9", "js", "wasip1":
+		return false
+	}
+
+	return true
+}
+
+func TestDeviceID(t *testing.T) {
+	t.Parallel()
+	fi, err := os.Stat(t.TempDir())
+	a.Got(err).NoError(t)
+	_, ok := DeviceID(fi)
+	a.Got(ok).Expect(hasStat()).Same(t)
+}
+
+func TestOwner(t *testing.T) {
+	t.Parallel()
+	fi, err := os.Stat(t.TempDir())
+	a.Got(err).NoError(t)
+	uid, _, ok := Owner(fi)
+	a.Got(ok).Expect(hasStat()).Same(t)
+	if ok {
+		a.Got(int(uid)).Expect(os.Getuid()).Same(t)
+	}
+}
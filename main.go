@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"github.com/bayashi/xfg/internal/xfgpager"
 	"github.com/bayashi/xfg/internal/xfgstats"
@@ -23,23 +26,28 @@ var funcExit = func(code int) {
 }
 
 type runner struct {
-	out      io.Writer
-	err      io.Writer
-	isTTY    bool
-	exitCode int
-	homeDir  string
-	procs    int
-	stats    *xfgstats.Stats
+	in        io.Reader
+	out       io.Writer
+	err       io.Writer
+	errMu     sync.Mutex // workers put errors at the same time
+	isTTY     bool
+	isPipedIn bool
+	exitCode  int
+	homeDir   string
+	procs     int
+	stats     *xfgstats.Stats
 }
 
 func main() {
 	procs := xfgutil.Procs()
 	cli := &runner{
-		out:   os.Stdout,
-		err:   os.Stderr,
-		isTTY: xfgutil.IsTTY(),
-		procs: procs,
-		stats: xfgstats.New(procs),
+		in:        os.Stdin,
+		out:       os.Stdout,
+		err:       os.Stderr,
+		isTTY:     xfgutil.IsTTY(),
+		isPipedIn: xfgutil.IsPipedStdin(),
+		procs:     procs,
+		stats:     xfgstats.New(procs),
 	}
 	exitCode, message := cli.run()
 
@@ -83,6 +91,12 @@ func (cli *runner) preXfg() (*options, error) {
 		o.NoColor = true // Turn off color
 	}
 
+	o.prepareStdin(cli.isPipedIn)
+
+	if err := o.prepareStartPaths(cli.homeDir); err != nil {
+		return nil, err
+	}
+
 	if o.Stats {
 		cli.stats.Mark("parseArgs")
 	}
@@ -96,6 +110,14 @@ func (cli *runner) preXfg() (*options, error) {
 
 func (cli *runner) xfg(o *options) (int, error) {
 	x := newX(cli, o)
+	defer x.result.paths.close()
+
+	if o.flagDebugIgnore != "" {
+		if err := x.debugIgnore(cli.out, o.flagDebugIgnore); err != nil {
+			return exitErr, fmt.Errorf("debugIgnore() : %w", err)
+		}
+		return exitOK, nil
+	}
 
 	// Initialize pager before process() for streaming display
 	if !x.options.NoPager && cli.isTTY {
@@ -119,7 +141,10 @@ func (cli *runner) xfg(o *options) (int, error) {
 		}
 	}
 
-	if err := x.process(); err != nil {
+	ctx, cancel := x.searchContext(context.Background())
+	defer cancel()
+
+	if err := x.process(ctx); err != nil {
 		return exitErr, fmt.Errorf("process() : %w", err)
 	}
 
@@ -151,10 +176,14 @@ func (cli *runner) xfg(o *options) (int, error) {
 
 	if x.options.Stats {
 		cli.stats.Mark("showResult")
-		cli.stats.SetPickedPaths(len(x.result.paths))
+		cli.stats.SetPickedPaths(x.result.pickedCount)
 
 		cli.stats.Show(cli.out)
 	}
 
+	if reason := stoppedReason(ctx); reason != "" {
+		return exitErr, errors.New(reason)
+	}
+
 	return cli.exitCode, nil
 }
@@ -2,9 +2,16 @@ package main
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	here "github.com/MakeNowJust/heredoc/v2"
 	a "github.com/bayashi/actually"
@@ -395,6 +402,66 @@ func TestXfg_OK(t *testing.T) {
 			`),
 			expectExitCode: exitOK,
 		},
+		"service-h with --lines": {
+			opt: &options{
+				SearchPath: []string{"service-h"},
+				SearchGrep: []string{"h"},
+				Indent:     defaultIndent,
+				Lines:      []string{"2:5", "11"},
+			},
+			expect: here.Doc(`
+                testdata/service-h/main.go
+                 4: 	hi()
+                 5: 	hello()
+                 11: func hello() {
+			`),
+			expectExitCode: exitOK,
+		},
+		"service-h with --lines from the end": {
+			opt: &options{
+				SearchPath: []string{"service-h"},
+				SearchGrep: []string{"h"},
+				Indent:     defaultIndent,
+				Lines:      []string{"-5:"},
+			},
+			expect: here.Doc(`
+                testdata/service-h/main.go
+                 8: func hi() {
+                 11: func hello() {
+			`),
+			expectExitCode: exitOK,
+		},
+		"service-h with --lines from the end, but no line number": {
+			opt: &options{
+				SearchPath:   []string{"service-h"},
+				SearchGrep:   []string{"h"},
+				Indent:       defaultIndent,
+				Lines:        []string{"-5:"},
+				NoLineNumber: true,
+			},
+			expect: here.Doc(`
+                testdata/service-h/main.go
+                 func hi() {
+                 func hello() {
+			`),
+			expectExitCode: exitOK,
+		},
+		"service-h with --lines and context": {
+			opt: &options{
+				SearchPath:        []string{"service-h"},
+				SearchGrep:        []string{"h"},
+				Indent:            defaultIndent,
+				Lines:             []string{":1"},
+				AfterContextLines: 2,
+			},
+			expect: here.Doc(`
+                testdata/service-h/main.go
+                 1: package h
+                 2: 
+                 3: func main() {
+			`),
+			expectExitCode: exitOK,
+		},
 		"service-h show count": {
 			opt: &options{
 				SearchPath:     []string{"service-h"},
@@ -546,6 +613,16 @@ func TestXfg_OK(t *testing.T) {
 			`),
 			expectExitCode: exitOK,
 		},
+		"not pick up ignorex dir due to .xfgignore in the start directory": {
+			opt: &options{
+				SearchPath: []string{"service-i"},
+				Type:       []string{"d"},
+			},
+			expect: here.Doc(`
+                testdata/service-i/
+			`),
+			expectExitCode: exitOK,
+		},
 		"pick up ignorex dir with --skip-xfgignore option": {
 			opt: &options{
 				SearchPath:    []string{"service-i"},
@@ -822,7 +899,7 @@ func TestXfg_OK(t *testing.T) {
 		"--type d service-d": {
 			opt: &options{
 				SearchPath: []string{"service-d"},
-				Type:       "d",
+				Type:       []string{"d"},
 			},
 			expect: here.Doc(`
                 testdata/service-d/
@@ -832,7 +909,7 @@ func TestXfg_OK(t *testing.T) {
 		"--type l": {
 			opt: &options{
 				SearchPath: []string{"service-p"},
-				Type:       "l",
+				Type:       []string{"l"},
 			},
 			expect: here.Doc(`
                 testdata/service-p/testlink
@@ -842,7 +919,7 @@ func TestXfg_OK(t *testing.T) {
 		"--type x": {
 			opt: &options{
 				SearchPath: []string{"service-p"},
-				Type:       "x",
+				Type:       []string{"x"},
 			},
 			expect: here.Doc(`
                 testdata/service-p/a.sh
@@ -853,13 +930,104 @@ func TestXfg_OK(t *testing.T) {
 		"--type e": {
 			opt: &options{
 				SearchPath: []string{"service-k"},
-				Type:       "e",
+				Type:       []string{"e"},
 			},
 			expect: here.Doc(`
                 testdata/service-k/foo.txt
 			`),
 			expectExitCode: exitOK,
 		},
+		"--size at least": {
+			opt: &options{
+				SearchPath: []string{"service-k"},
+				Size:       []string{"+1"},
+			},
+			expect: here.Doc(`
+                testdata/service-k/bar.pl
+			`),
+			expectExitCode: exitOK,
+		},
+		"--size at most": {
+			opt: &options{
+				SearchPath: []string{"service-a"},
+				Size:       []string{"-1k"},
+			},
+			expect: here.Doc(`
+                testdata/service-a/a.dat
+                testdata/service-a/main.go
+			`),
+			expectExitCode: exitOK,
+		},
+		"--size range": {
+			opt: &options{
+				SearchPath: []string{"service-a"},
+				Size:       []string{"+2", "-100"},
+			},
+			expect: here.Doc(`
+                testdata/service-a/main.go
+			`),
+			expectExitCode: exitOK,
+		},
+		"--max-filesize": {
+			opt: &options{
+				SearchPath:  []string{"service-"},
+				SearchGrep:  []string{"package"},
+				MaxFilesize: "38",
+			},
+			expect: here.Doc(`
+                testdata/service-a/main.go
+                1: package a
+                
+                testdata/service-b/main.go
+                1: package b
+                
+                testdata/service-m/foo.pm
+                1: package foo
+			`),
+			expectExitCode: exitOK,
+		},
+		"--type f": {
+			opt: &options{
+				SearchPath: []string{"service-p"},
+				Type:       []string{"f"},
+			},
+			expect: here.Doc(`
+                testdata/service-p/a.sh
+			`),
+			expectExitCode: exitOK,
+		},
+		"--type f,l": {
+			opt: &options{
+				SearchPath: []string{"service-p"},
+				Type:       []string{"f,l"},
+			},
+			expect: here.Doc(`
+                testdata/service-p/a.sh
+                testdata/service-p/testlink
+			`),
+			expectExitCode: exitOK,
+		},
+		"--type !d": {
+			opt: &options{
+				SearchPath: []string{"service-k"},
+				Type:       []string{"!d"},
+			},
+			expect: here.Doc(`
+                testdata/service-k/bar.pl
+                testdata/service-k/foo.txt
+			`),
+			expectExitCode: exitOK,
+		},
+		"--type f and !e": {
+			opt: &options{
+				SearchPath: []string{"service-k"},
+				Type:       []string{"file", "!empty"},
+			},
+			expect: here.Doc(`
+                testdata/service-k/bar.pl
+			`),
+			expectExitCode: exitOK,
+		},
 		"pick *min.js etc with --hidden and --no-default-skip": {
 			opt: &options{
 				SearchPath:    []string{"service-q"},
@@ -948,6 +1116,113 @@ func TestXfg_OK(t *testing.T) {
 			`),
 			expectExitCode: exitOK,
 		},
+		"--one-file-system": {
+			opt: &options{
+				SearchPath:    []string{"service-s"},
+				SearchGrep:    []string{"bar"},
+				OneFileSystem: true,
+			},
+			expect: here.Doc(`
+                testdata/service-s/d3/d3.txt
+                1: bar
+                
+                testdata/service-s/d3/d4/d4.txt
+                1: bar
+			`),
+			expectExitCode: exitOK,
+		},
+		"Not pick up shallower paths than minDepth": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				MinDepth:   3,
+			},
+			expect: here.Doc(`
+                testdata/service-s/d3/d3.txt
+                testdata/service-s/d3/d4/
+                testdata/service-s/d3/d4/d4.txt
+			`),
+			expectExitCode: exitOK,
+		},
+		"Pick up only d4 by minDepth with grep": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				SearchGrep: []string{"bar"},
+				MinDepth:   4,
+			},
+			expect: here.Doc(`
+                testdata/service-s/d3/d4/d4.txt
+                1: bar
+			`),
+			expectExitCode: exitOK,
+		},
+		"--show-depth": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				ShowDepth:  true,
+			},
+			expect: here.Doc(`
+                1:testdata/service-s/
+                2:testdata/service-s/d3/
+                3:testdata/service-s/d3/d3.txt
+                3:testdata/service-s/d3/d4/
+                4:testdata/service-s/d3/d4/d4.txt
+			`),
+			expectExitCode: exitOK,
+		},
+		"--sort depth with --dirs-first": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				Sort:       "depth",
+				DirsFirst:  true,
+			},
+			expect: here.Doc(`
+                testdata/service-s/
+                testdata/service-s/d3/
+                testdata/service-s/d3/d4/
+                testdata/service-s/d3/d3.txt
+                testdata/service-s/d3/d4/d4.txt
+			`),
+			expectExitCode: exitOK,
+		},
+		"--sortr path": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				SortR:      "path",
+			},
+			expect: here.Doc(`
+                testdata/service-s/d3/d4/d4.txt
+                testdata/service-s/d3/d4/
+                testdata/service-s/d3/d3.txt
+                testdata/service-s/d3/
+                testdata/service-s/
+			`),
+			expectExitCode: exitOK,
+		},
+		"--max-results with grep": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				SearchGrep: []string{"bar"},
+				MaxResults: 1,
+				Threads:    1,
+			},
+			expect: here.Doc(`
+                testdata/service-s/d3/d3.txt
+                1: bar
+			`),
+			expectExitCode: exitOK,
+		},
+		"--max-results paths": {
+			opt: &options{
+				SearchPath: []string{"service-s"},
+				MaxResults: 2,
+				Threads:    1,
+			},
+			expect: here.Doc(`
+                testdata/service-s/
+                testdata/service-s/d3/
+			`),
+			expectExitCode: exitOK,
+		},
 		"Pick up until d4 by enough maxDepth": {
 			opt: &options{
 				SearchPath: []string{"service-s"},
@@ -971,32 +1246,55 @@ func TestXfg_OK(t *testing.T) {
 		tt := tt
 		t.Run(tname, func(t *testing.T) {
 			t.Parallel()
-			var o bytes.Buffer
-			cli := &runner{
-				out:   &o,
-				isTTY: true,
-				stats: xfgstats.New(1),
-			}
+			code, out, errOut := testXfg(t, &runner{isTTY: true}, tt.opt)
+			a.Got(code).Expect(tt.expectExitCode).Debug("err", errOut).Same(t)
 
-			tt.opt.NoPager = true
-			tt.opt.NoColor = true
-			if tt.opt.SearchStart == nil {
-				tt.opt.SearchStart = []string{"./testdata"}
-			}
-			if tt.opt.MaxDepth == 0 {
-				tt.opt.MaxDepth = defaultMaxDepth
-			}
-			tt.opt.KeepResultOrder = true
+			tt.expect = windowsBK(tt.expect)
+			a.Got(out).Expect(tt.expect).X().Debug("options", tt.opt).Same(t)
+		})
+	}
+}
 
-			code, err := cli.xfg(tt.opt)
+// testXfg runs xfg like main() without pager, and returns the exit code, the output and the error output.
+// Options are parsed from args if opt is nil. Otherwise opt is used as is in order without color, and
+// its start path is ./testdata by default. cli can be nil for a runner without terminal and stdin
+func testXfg(t *testing.T, cli *runner, opt *options, args ...string) (int, string, string) {
+	t.Helper()
+	if cli == nil {
+		cli = &runner{}
+	}
+	var o, e bytes.Buffer
+	cli.out = &o
+	cli.err = &e
+	cli.stats = xfgstats.New(1)
 
-			a.Got(err).Debug("options", tt.opt).NoError(t)
-			a.Got(code).Expect(tt.expectExitCode).Same(t)
+	code, msg := exitOK, ""
+	if opt == nil {
+		resetFlag()
+		stubExit()
+		os.Args = append([]string{fakeCmd, "--no-pager"}, args...)
+		code, msg = cli.run()
+	} else {
+		opt.NoPager = true
+		opt.NoColor = true
+		if opt.SearchStart == nil {
+			opt.SearchStart = []string{"./testdata"}
+		}
+		if opt.MaxDepth == 0 {
+			opt.MaxDepth = defaultMaxDepth
+		}
+		opt.KeepResultOrder = true
 
-			tt.expect = windowsBK(tt.expect)
-			a.Got(o.String()).Expect(tt.expect).X().Debug("options", tt.opt).Same(t)
-		})
+		var err error
+		if code, err = cli.xfg(opt); err != nil {
+			code, msg = exitErr, err.Error()
+		}
+	}
+	if msg != "" {
+		cli.putErr(fmt.Sprintf("Err: %s", msg))
 	}
+
+	return code, o.String(), e.String()
 }
 
 // no color, no pager
@@ -1068,3 +1366,752 @@ func TestNonTTY(t *testing.T) {
 		})
 	}
 }
+
+func TestStdin(t *testing.T) {
+	for tname, tt := range map[string]struct {
+		args   []string
+		in     string
+		expect string
+	}{
+		"piped stdin without --start": {
+			args: []string{"-g", "ERROR"},
+			in:   "INFO foo\nERROR bar\nINFO baz\n",
+			expect: here.Doc(`
+			    <stdin>:2:ERROR bar
+			`),
+		},
+		"piped stdin with `-` as --start": {
+			args: []string{"-s", "-", "-g", "ERROR", "--no-line-number"},
+			in:   "ERROR foo\nINFO bar\n",
+			expect: here.Doc(`
+			    <stdin>:ERROR foo
+			`),
+		},
+		"piped stdin, but --start is given": {
+			args: []string{"-s", "./testdata", "service-b", "func"},
+			in:   "func\n",
+			expect: here.Doc(`
+			    testdata/service-b/main.go:3:func main() {
+			`),
+		},
+		"piped stdin, but a path keyword is given": {
+			args: []string{"service-b", "func"},
+			in:   "func\n",
+			expect: here.Doc(`
+			    testdata/service-b/main.go:3:func main() {
+			`),
+		},
+		"piped stdin with --lines from the end": {
+			args: []string{"-g", "ERROR", "--lines", "-2:"},
+			in:   "ERROR foo\nINFO bar\nERROR baz\nINFO qux\n",
+			expect: here.Doc(`
+			    <stdin>:3:ERROR baz
+			`),
+		},
+		"piped stdin, but no match": {
+			args:   []string{"-g", noMatchKeyword},
+			in:     "INFO foo\n",
+			expect: "",
+		},
+		"binary stdin which has NUL after 4KiB": {
+			args:   []string{"-g", "ERROR"},
+			in:     strings.Repeat("ERROR foo\n", 500) + "\x00",
+			expect: "",
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			cli := &runner{in: strings.NewReader(tt.in), isPipedIn: true}
+			code, out, errOut := testXfg(t, cli, nil, tt.args...)
+			a.Got(code).Expect(exitOK).Debug("err", errOut).Same(t)
+			a.Got(out).Expect(windowsBK(tt.expect)).X().Same(t)
+		})
+	}
+}
+
+func TestStdin_Err(t *testing.T) {
+	code, _, errOut := testXfg(t, &runner{in: strings.NewReader("foo\n")}, nil, "-s", "-", "foo")
+	a.Got(code).Expect(exitErr).Same(t)
+	a.Got(errOut).Expect(`need a keyword to grep contents`).Match(t)
+}
+
+func TestStdin_ReadErr(t *testing.T) {
+	code, _, errOut := testXfg(t, &runner{in: iotest.ErrReader(errors.New("broken pipe"))}, nil, "-s", "-", "-g", "foo")
+	a.Got(code).Expect(exitErr).Same(t)
+	a.Got(errOut).Expect("could not read `<stdin>` : broken pipe").Match(t)
+}
+
+func writeTestFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for p, content := range files {
+		fPath := filepath.Join(root, p)
+		a.Got(os.MkdirAll(filepath.Dir(fPath), 0755)).NoError(t)
+		a.Got(os.WriteFile(fPath, []byte(content), 0644)).NoError(t)
+	}
+}
+
+func expectPaths(root string, paths ...string) string {
+	expect := ""
+	for _, p := range paths {
+		expect = expect + filepath.Join(root, p)
+		if strings.HasSuffix(p, "/") {
+			expect = expect + string(filepath.Separator)
+		}
+		expect = expect + "\n"
+	}
+
+	return expect
+}
+
+func TestGitignoreSemantics(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".gitignore":     "build/\n*.log\n/top.txt\n",
+		"app/.gitignore": "!keep.log\n/local.txt\n",
+		"build/a.txt":    "",
+		"app/build":      "", // not a directory
+		"app/keep.log":   "",
+		"app/x.log":      "",
+		"app/local.txt":  "",
+		"app/top.txt":    "",
+		"local.txt":      "",
+		"top.txt":        "",
+	})
+
+	code, out, errOut := testXfg(t, nil, nil, "--keep-result-order", "--skip-xfgignore", "-s", root)
+	a.Got(code).Expect(exitOK).Debug("err", errOut).Same(t)
+	a.Got(out).Expect(expectPaths(root, "app/", "app/build", "app/keep.log", "app/top.txt", "local.txt")).X().Same(t)
+
+	for p, expect := range map[string]string{
+		"build/a.txt":   "is ignored, because `" + filepath.Join(root, "build") + "` is ignored by `build/` at line 1",
+		"app/build":     "is not ignored by any rule",
+		"app/keep.log":  "is not ignored, because it is re-included by `!keep.log` at line 1",
+		"app/x.log":     "is ignored by `*.log` at line 2",
+		"app/local.txt": "is ignored by `/local.txt` at line 2",
+		"local.txt":     "is not ignored by any rule",
+		"top.txt":       "is ignored by `/top.txt` at line 3",
+	} {
+		_, got, _ := testXfg(t, nil, nil, "--skip-xfgignore", "-s", root, "--debug-ignore", filepath.Join(root, p))
+		a.Got(strings.HasPrefix(got, "`"+filepath.Join(root, p)+"` "+expect)).Debug("got", got).True(t)
+	}
+}
+
+func TestDirIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".gitignore":     "*.txt\n",
+		".ignore":        "!*.txt\na.txt\n",
+		".rgignore":      "b.txt\n",
+		".fdignore":      "c.txt\n",
+		"sub/.xfgignore": "d.txt\n",
+		"a.txt":          "",
+		"b.txt":          "",
+		"c.txt":          "",
+		"d.txt":          "",
+		"sub/a.txt":      "",
+		"sub/d.txt":      "",
+		"sub/ok.txt":     "",
+	})
+
+	for tname, tt := range map[string]struct {
+		args   []string
+		expect []string
+	}{
+		"default": {
+			expect: []string{"d.txt", "sub/", "sub/ok.txt"},
+		},
+		"--skip-dot-ignore": {
+			args:   []string{"--skip-dot-ignore"},
+			expect: []string{"sub/"},
+		},
+		"--skip-rgignore": {
+			args:   []string{"--skip-rgignore"},
+			expect: []string{"b.txt", "d.txt", "sub/", "sub/ok.txt"},
+		},
+		"--skip-fdignore": {
+			args:   []string{"--skip-fdignore"},
+			expect: []string{"c.txt", "d.txt", "sub/", "sub/ok.txt"},
+		},
+		"--skip-xfgignore": {
+			args:   []string{"--skip-xfgignore"},
+			expect: []string{"d.txt", "sub/", "sub/d.txt", "sub/ok.txt"},
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			code, out, errOut := testXfg(t, nil, nil, append([]string{"--keep-result-order", "-s", root}, tt.args...)...)
+			a.Got(code).Expect(exitOK).Debug("err", errOut).Same(t)
+			a.Got(out).Expect(expectPaths(root, tt.expect...)).X().Same(t)
+		})
+	}
+
+	_, got, _ := testXfg(t, nil, nil, "--debug-ignore", filepath.Join(root, "sub", "d.txt"))
+	a.Got(strings.Contains(got, "is ignored by `d.txt` at line 1 of `"+filepath.Join(root, "sub", ".xfgignore")+"`")).Debug("got", got).True(t)
+}
+
+func TestStartPaths(t *testing.T) {
+	ignoredDir := t.TempDir()
+	writeTestFiles(t, ignoredDir, map[string]string{
+		".gitignore": "*.log\n",
+		"x.log":      "foo\n",
+	})
+
+	for tname, tt := range map[string]struct {
+		args   []string
+		expect string
+	}{
+		"file": {
+			args: []string{"-s", "testdata/service-b/main.go", "-g", "func"},
+			expect: here.Doc(`
+			    testdata/service-b/main.go:3:func main() {
+			`),
+		},
+		"glob": {
+			args: []string{"-s", "testdata/service-[bc]", "-g", "package"},
+			expect: here.Doc(`
+			    testdata/service-b/main.go:1:package b
+			    testdata/service-c/main.go:1:package c
+			`),
+		},
+		"overlapping": {
+			args: []string{"-s", "testdata/service-b", "-s", "testdata/service-b/main.go", "-g", "package"},
+			expect: here.Doc(`
+			    testdata/service-b/main.go:1:package b
+			`),
+		},
+		"hidden file": {
+			args: []string{"-s", "testdata/.gitignore", "-g", "ignorez"},
+			expect: here.Doc(`
+			    testdata/.gitignore:1:ignorez
+			`),
+		},
+		"file with --min-depth": {
+			args: []string{"-s", "testdata/service-b/main.go", "-g", "func", "--min-depth", "1"},
+			expect: here.Doc(`
+			    testdata/service-b/main.go:3:func main() {
+			`),
+		},
+		"ignored file": {
+			args:   []string{"-s", filepath.Join(ignoredDir, "x.log"), "-g", "foo"},
+			expect: filepath.Join(ignoredDir, "x.log") + ":1:foo\n",
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			code, out, errOut := testXfg(t, nil, nil, append([]string{"--keep-result-order"}, tt.args...)...)
+			a.Got(code).Expect(exitOK).Debug("err", errOut).Same(t)
+			a.Got(out).Expect(windowsBK(tt.expect)).X().Same(t)
+		})
+	}
+}
+
+func TestFilesFrom(t *testing.T) {
+	filesFromPath := filepath.Join(t.TempDir(), "files")
+	err := os.WriteFile(filesFromPath, []byte(windowsBK("testdata/service-a/main.go\ntestdata/service-b/main.go\ntestdata/service-k/bar.pl\n")), 0644)
+	a.Got(err).NoError(t)
+
+	for tname, tt := range map[string]struct {
+		args   []string
+		in     string
+		expect string
+	}{
+		"--files-from file": {
+			args: []string{"--files-from", filesFromPath},
+			expect: here.Doc(`
+			    testdata/service-a/main.go
+			    testdata/service-b/main.go
+			    testdata/service-k/bar.pl
+			`),
+		},
+		"--files-from file with path and grep": {
+			args: []string{"--files-from", filesFromPath, "main", "package"},
+			expect: here.Doc(`
+			    testdata/service-a/main.go:1:package a
+			    testdata/service-b/main.go:1:package b
+			`),
+		},
+		"--files-from file with --ext": {
+			args: []string{"--files-from", filesFromPath, "--ext", "pl"},
+			expect: here.Doc(`
+			    testdata/service-k/bar.pl
+			`),
+		},
+		"--files-from stdin": {
+			args: []string{"--files-from", "-", "-g", "func"},
+			in:   windowsBK("testdata/service-b/main.go\n"),
+			expect: here.Doc(`
+			    testdata/service-b/main.go:3:func main() {
+			`),
+		},
+		"--files-from stdin with --null-data": {
+			args: []string{"--files-from", "-", "--null-data", "-g", "package"},
+			in:   windowsBK("testdata/service-b/main.go\x00testdata/service-c/main.go\x00"),
+			expect: here.Doc(`
+			    testdata/service-b/main.go:1:package b
+			    testdata/service-c/main.go:1:package c
+			`),
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			cli := &runner{in: strings.NewReader(tt.in), isPipedIn: true}
+			code, out, errOut := testXfg(t, cli, nil, append([]string{"--keep-result-order"}, tt.args...)...)
+			a.Got(code).Expect(exitOK).Debug("err", errOut).Same(t)
+			a.Got(out).Expect(windowsBK(tt.expect)).X().Same(t)
+		})
+	}
+}
+
+func TestFilesFrom_IgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		".git/HEAD":       "ref: refs/heads/main\n",
+		".gitignore":      "build/\n*.log\n",
+		"sub/.gitignore":  "!keep.log\n",
+		"build/a.txt":     "",
+		"top.log":         "",
+		"sub/keep.log":    "",
+		"sub/x.log":       "",
+		"sub/ok.txt":      "",
+		"sub/build/b.txt": "",
+	})
+	t.Chdir(filepath.Join(root, "sub"))
+
+	in := strings.Join([]string{
+		filepath.Join("..", "build", "a.txt"),
+		filepath.Join("..", "top.log"),
+		"keep.log",
+		"x.log",
+		"ok.txt",
+		filepath.Join(root, "sub", "build", "b.txt"),
+		filepath.Join(root, "sub", "ok.txt"),
+	}, "\n")
+
+	for tname, tt := range map[string]struct {
+		args   []string
+		expect []string
+	}{
+		"rules of the repository": {
+			expect: []string{filepath.Join(root, "sub", "ok.txt"), "keep.log", "ok.txt"},
+		},
+		"--search-all": {
+			args: []string{"--search-all"},
+			expect: []string{
+				filepath.Join("..", "build", "a.txt"),
+				filepath.Join("..", "top.log"),
+				filepath.Join(root, "sub", "build", "b.txt"),
+				filepath.Join(root, "sub", "ok.txt"),
+				"keep.log",
+				"ok.txt",
+				"x.log",
+			},
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			cli := &runner{in: strings.NewReader(in), isPipedIn: true}
+			code, out, errOut := testXfg(t, cli, nil, append([]string{"--keep-result-order", "--skip-xfgignore", "--files-from", "-"}, tt.args...)...)
+			a.Got(code).Expect(exitOK).Debug("err", errOut).Same(t)
+			a.Got(out).Expect(strings.Join(tt.expect, "\n") + "\n").X().Same(t)
+		})
+	}
+}
+
+func TestFilesFrom_WithStart(t *testing.T) {
+	cli := &runner{in: strings.NewReader("testdata/service-a/main.go\n"), isPipedIn: true}
+	code, _, errOut := testXfg(t, cli, nil, "--files-from", "-", "-s", "testdata")
+	a.Got(code).Expect(exitErr).Same(t)
+	a.Got(errOut).Expect("could not use both --files-from and --start").Match(t)
+}
+
+func TestLongLine(t *testing.T) {
+	tempDir := t.TempDir()
+	longLine := strings.Repeat("x", 100000)
+	err := os.WriteFile(filepath.Join(tempDir, "long.txt"), []byte("foo\n"+longLine+"foo\nbar foo\n"), 0644)
+	a.Got(err).NoError(t)
+
+	for tname, tt := range map[string]struct {
+		opt       *options
+		expect    string
+		expectErr string
+	}{
+		"no limit": {
+			opt: &options{
+				SearchGrep: []string{"bar"},
+			},
+			expect: windowsBK(tempDir+"/long.txt") + "\n3: bar foo\n",
+		},
+		"truncated by --max-line-bytes": {
+			opt: &options{
+				SearchGrep:   []string{"foo"},
+				MaxLineBytes: 10,
+			},
+			expect: windowsBK(tempDir+"/long.txt") + "\n1: foo\n3: bar foo\n",
+		},
+		"skip the file by --skip-long-line-file": {
+			opt: &options{
+				SearchGrep:       []string{"bar"},
+				MaxLineBytes:     10,
+				SkipLongLineFile: true,
+			},
+			expect:    "",
+			expectErr: "line 2 is longer than 10 bytes",
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			tt.opt.SearchStart = []string{tempDir}
+			code, out, errOut := testXfg(t, &runner{isTTY: true}, tt.opt)
+			a.Got(code).Expect(exitOK).Same(t)
+			a.Got(out).Expect(tt.expect).X().Same(t)
+			if tt.expectErr != "" {
+				a.Got(errOut).Expect(tt.expectErr).Match(t)
+			}
+		})
+	}
+}
+
+func TestLineEnding(t *testing.T) {
+	tempDir := t.TempDir()
+	for name, content := range map[string]string{
+		"lf.txt":    "foo\nbar\n",
+		"crlf.txt":  "foo\r\nbar\r\n",
+		"mixed.txt": "foo\r\nbar\n",
+		"none.txt":  "foo",
+		"split.txt": strings.Repeat("x", 4095) + "\r\nfoo\r\n", // CR at the end of the read buffer
+	} {
+		err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644)
+		a.Got(err).NoError(t)
+	}
+	p := func(name string) string {
+		return windowsBK(tempDir+"/"+name) + "\n"
+	}
+
+	for tname, tt := range map[string]struct {
+		opt    *options
+		expect string
+	}{
+		"strip CR to match regexp": {
+			opt: &options{
+				SearchPath:   []string{"crlf"},
+				SearchGrepRe: []string{"foo$"},
+			},
+			expect: p("crlf.txt") + "1: foo\n",
+		},
+		"--line-ending lf": {
+			opt: &options{
+				LineEnding: []string{"lf"},
+			},
+			expect: p("lf.txt"),
+		},
+		"--line-ending crlf": {
+			opt: &options{
+				LineEnding: []string{"crlf"},
+			},
+			expect: p("crlf.txt") + p("split.txt"),
+		},
+		"--line-ending crlf with --max-line-bytes": {
+			opt: &options{
+				LineEnding:   []string{"crlf"},
+				MaxLineBytes: 10,
+			},
+			expect: p("crlf.txt") + p("split.txt"),
+		},
+		"--line-ending mixed with grep": {
+			opt: &options{
+				LineEnding: []string{"mixed"},
+				SearchGrep: []string{"bar"},
+			},
+			expect: p("mixed.txt") + "2: bar\n",
+		},
+		"--crlf": {
+			opt: &options{
+				CRLF: true,
+			},
+			expect: p("crlf.txt") + p("mixed.txt") + p("split.txt"),
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			tt.opt.SearchStart = []string{tempDir}
+			code, out, _ := testXfg(t, &runner{isTTY: true}, tt.opt)
+			a.Got(code).Expect(exitOK).Same(t)
+			a.Got(out).Expect(tt.expect).X().Same(t)
+		})
+	}
+}
+
+func TestFollow(t *testing.T) {
+	if isWindowsTestRunner() {
+		t.Skip("symbolic links need privileges on Windows")
+	}
+
+	tempDir := t.TempDir()
+	a.Got(os.MkdirAll(filepath.Join(tempDir, "real"), 0755)).NoError(t)
+	a.Got(os.MkdirAll(filepath.Join(tempDir, "a"), 0755)).NoError(t)
+	a.Got(os.WriteFile(filepath.Join(tempDir, "real", "foo.txt"), []byte("hello\n"), 0644)).NoError(t)
+	a.Got(os.Symlink(filepath.Join("..", "real"), filepath.Join(tempDir, "a", "link"))).NoError(t)
+	a.Got(os.Symlink(filepath.Join("..", "real", "foo.txt"), filepath.Join(tempDir, "a", "file-link"))).NoError(t)
+	a.Got(os.Symlink("..", filepath.Join(tempDir, "a", "loop"))).NoError(t)
+	a.Got(os.Symlink("nowhere", filepath.Join(tempDir, "broken"))).NoError(t)
+
+	for tname, tt := range map[string]struct {
+		follow    bool
+		expect    string
+		expectErr []string
+	}{
+		"not follow by default": {
+			follow: false,
+			expect: tempDir + "/real/foo.txt\n1: hello\n",
+		},
+		"--follow": {
+			follow: true,
+			expect: tempDir + "/a/file-link\n1: hello\n\n" +
+				tempDir + "/a/link/foo.txt\n1: hello\n\n" +
+				tempDir + "/real/foo.txt\n1: hello\n",
+			expectErr: []string{
+				"file system loop found: `" + tempDir + "/a/loop`",
+				"broken symbolic link: `" + tempDir + "/broken`",
+			},
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			code, out, errOut := testXfg(t, &runner{isTTY: true}, &options{
+				SearchGrep:  []string{"hello"},
+				Follow:      tt.follow,
+				SearchStart: []string{tempDir},
+			})
+			a.Got(code).Expect(exitOK).Same(t)
+			a.Got(out).Expect(tt.expect).X().Same(t)
+			for _, expectErr := range tt.expectErr {
+				a.Got(strings.Contains(errOut, expectErr)).Debug("stderr", errOut).True(t)
+			}
+		})
+	}
+}
+
+func TestOrderedStream_SameAsKeepResultOrder(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"a/b/x.txt": "foo\n",
+		"a/y.txt":   "foo\n",
+		"a.txt":     "foo\n", // sorted between `a` and `a/b`
+		"a-b/z.txt": "foo\n",
+		"b.txt":     "foo\n",
+	})
+
+	for _, args := range [][]string{
+		{"main", "-s", "testdata"},
+		{"", "-g", "func", "-s", "testdata"},
+		{"", "-s", root},
+		{"", "-g", "foo", "-s", root},
+	} {
+		_, expect, _ := testXfg(t, nil, nil, append(args, "--keep-result-order")...)
+		a.Got(expect != "").True(t)
+		for _, mode := range [][]string{{"-j1"}, {"--ordered-stream", "-j1"}, {"--ordered-stream", "-j4"}} {
+			_, got, _ := testXfg(t, nil, nil, append(args, mode...)...)
+			a.Got(got).Expect(expect).X().Debug("args", append(args, mode...)).Same(t)
+		}
+	}
+}
+
+// --deterministic shows results in the canonical order, which is the same as --keep-result-order
+func TestDeterministic(t *testing.T) {
+	// --keep-result-order and --sort turn off --ordered-stream, but not --deterministic
+	for _, args := range [][]string{
+		{"", "-g", "func"},
+		{"main"},
+		{"", "-g", "func", "--max-results", "3"},
+		{"", "-g", "func", "--max-results", "3", "--keep-result-order"},
+		{"main", "--max-results", "2", "--sortr", "path"},
+	} {
+		args = append(args, "-s", "testdata")
+		_, expect, _ := testXfg(t, nil, nil, append(args, "--keep-result-order", "-j1")...)
+		a.Got(expect != "").True(t)
+		for _, threads := range []string{"-j1", "-j2", "-j4", "-j8"} {
+			_, got, _ := testXfg(t, nil, nil, append(args, "--deterministic", threads)...)
+			a.Got(got).Expect(expect).X().Debug("args", append(args, threads)).Same(t)
+		}
+	}
+}
+
+func TestMmap_SameAsRead(t *testing.T) {
+	for _, args := range [][]string{
+		{"", "-g", "func", "--keep-result-order"},
+		{"", "-g", "main", "-C", "1", "--keep-result-order"},
+		{"", "-G", "fun.", "--lines", "-3:", "--keep-result-order"},
+		{"", "--line-ending", "lf", "--keep-result-order"},
+	} {
+		_, read, _ := testXfg(t, nil, nil, append(args, "-s", "testdata", "--stats", "--no-mmap")...)
+		_, mapped, _ := testXfg(t, nil, nil, append(args, "-s", "testdata", "--stats", "--mmap")...)
+		a.Got(read).Expect(`mmap: 0\n`).Match(t)
+		a.Got(mapped).Expect(`read: 0\n`).Match(t)
+		a.Got(mapped[:strings.Index(mapped, "[Lap]")]).Expect(read[:strings.Index(read, "[Lap]")]).Same(t)
+	}
+}
+
+func TestMmap_BinaryAfter4KiB(t *testing.T) {
+	tempDir := t.TempDir()
+	content := strings.Repeat("ERROR foo\n", 500) + "\x00"
+	a.Got(os.WriteFile(filepath.Join(tempDir, "bin.dat"), []byte(content), 0644)).NoError(t)
+
+	for tname, opt := range map[string]*options{
+		"--mmap":    {Mmap: true},
+		"--no-mmap": {NoMmap: true},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			opt.SearchGrep = []string{"ERROR"}
+			opt.SearchStart = []string{tempDir}
+			code, out, _ := testXfg(t, &runner{isTTY: true}, opt)
+			a.Got(code).Expect(exitOK).Same(t)
+			a.Got(out).Expect("").Same(t)
+		})
+	}
+}
+
+// slowReader blocks long enough on reading so that a short --timeout has expired surely before the content is scanned
+type slowReader struct {
+	io.Reader
+	wait time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.wait)
+	return r.Reader.Read(p)
+}
+
+func TestTimeout(t *testing.T) {
+	cli := &runner{in: &slowReader{Reader: strings.NewReader("foo\n"), wait: 200 * time.Millisecond}}
+	code, _, errOut := testXfg(t, cli, nil, "-s", "-", "-g", "foo", "--timeout", "1ms")
+	a.Got(code).Expect(exitErr).Same(t)
+	a.Got(errOut).Expect("timed out. results are partial").Match(t)
+}
+
+func TestModTime(t *testing.T) {
+	tempDir := t.TempDir()
+	now := time.Now()
+	for name, mt := range map[string]time.Time{
+		"old.txt":    now.Add(-30 * 24 * time.Hour),
+		"middle.txt": now.Add(-2 * time.Hour),
+		"new.txt":    now.Add(-1 * time.Minute),
+	} {
+		fPath := filepath.Join(tempDir, name)
+		a.Got(os.WriteFile(fPath, []byte("host-a\n"), 0644)).NoError(t)
+		a.Got(os.Chtimes(fPath, mt, mt)).NoError(t)
+	}
+	p := func(name string) string {
+		return windowsBK(tempDir+"/"+name) + "\n"
+	}
+
+	for tname, tt := range map[string]struct {
+		opt    *options
+		expect string
+	}{
+		"--changed-within": {
+			opt: &options{
+				SearchPath:    []string{".txt"},
+				ChangedWithin: "1h",
+			},
+			expect: p("new.txt"),
+		},
+		"--changed-within with grep": {
+			opt: &options{
+				SearchGrep:    []string{"host-a"},
+				ChangedWithin: "1d",
+			},
+			expect: p("middle.txt") + "1: host-a\n\n" + p("new.txt") + "1: host-a\n",
+		},
+		"--changed-before": {
+			opt: &options{
+				SearchPath:    []string{".txt"},
+				ChangedBefore: "1d",
+			},
+			expect: p("old.txt"),
+		},
+		"--newer": {
+			opt: &options{
+				SearchPath: []string{".txt"},
+				Newer:      filepath.Join(tempDir, "middle.txt"),
+			},
+			expect: p("new.txt"),
+		},
+		"--older": {
+			opt: &options{
+				SearchPath: []string{".txt"},
+				Older:      filepath.Join(tempDir, "middle.txt"),
+			},
+			expect: p("old.txt"),
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			tt.opt.SearchStart = []string{tempDir}
+			code, out, _ := testXfg(t, &runner{isTTY: true}, tt.opt)
+			a.Got(code).Expect(exitOK).Same(t)
+			a.Got(out).Expect(tt.expect).X().Same(t)
+		})
+	}
+}
+
+func TestOwnerPerm(t *testing.T) {
+	if isWindowsTestRunner() {
+		t.Skip("Windows doesn't have owners and permissions of Unix")
+	}
+
+	tempDir := t.TempDir()
+	for name, perm := range map[string]fs.FileMode{
+		"private.txt":        0600,
+		"public.txt":         0644,
+		"world-writable.txt": 0666,
+	} {
+		fPath := filepath.Join(tempDir, name)
+		a.Got(os.WriteFile(fPath, []byte("secret\n"), perm)).NoError(t)
+		a.Got(os.Chmod(fPath, perm)).NoError(t)
+	}
+	p := func(name string) string {
+		return tempDir + "/" + name + "\n"
+	}
+
+	for tname, tt := range map[string]struct {
+		opt    *options
+		expect string
+	}{
+		"--perm exactly": {
+			opt: &options{
+				Perm: []string{"0600"},
+			},
+			expect: p("private.txt"),
+		},
+		"--perm any bits": {
+			opt: &options{
+				Perm: []string{"/o+w"},
+			},
+			expect: p("world-writable.txt"),
+		},
+		"--perm all bits with grep": {
+			opt: &options{
+				Perm:       []string{"-0644"},
+				SearchGrep: []string{"secret"},
+			},
+			expect: p("public.txt") + "1: secret\n\n" + p("world-writable.txt") + "1: secret\n",
+		},
+		"--owner": {
+			opt: &options{
+				Owner: fmt.Sprintf("%d", os.Getuid()),
+				Perm:  []string{"0600"},
+			},
+			expect: p("private.txt"),
+		},
+		"--owner not match": {
+			opt: &options{
+				Owner: fmt.Sprintf("%d", os.Getuid()+1),
+			},
+			expect: "",
+		},
+		"--setuid": {
+			opt: &options{
+				Setuid: true,
+			},
+			expect: "",
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			tt.opt.SearchStart = []string{tempDir}
+			code, out, _ := testXfg(t, &runner{isTTY: true}, tt.opt)
+			a.Got(code).Expect(exitOK).Same(t)
+			a.Got(out).Expect(tt.expect).X().Same(t)
+		})
+	}
+}
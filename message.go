@@ -5,13 +5,27 @@ import (
 	"strings"
 )
 
-const supportTypes = "directory (d), symlink (l), executable (x), empty (e), socket (s), pipe (p), block-device (b), char-device (c)"
+const supportSortKeys = "path, name, mtime, size, matches, depth, ext"
+
+const supportTypes = "file (f), directory (d), symlink (l), executable (x), empty (e), socket (s), pipe (p), block-device (b), char-device (c)"
 
 var message = map[string]map[string]string{
 	"help_Stats": {
 		"en": "Print runtime stats after searching result",
 		"ja": "検索結果の後に、実行した処理の統計を表示します",
 	},
+	"help_MaxResults": {
+		"en": "Stop whole searching after NUM results. A result is a matching line on content search, otherwise a path",
+		"ja": "NUM 件の結果で検索全体を止める。コンテンツ検索ではマッチした行、それ以外はパスを1件と数える",
+	},
+	"help_Timeout": {
+		"en": "Stop searching after this duration like '10s', and show partial results",
+		"ja": "'10s' のような時間で検索を止めて、途中までの結果を表示する",
+	},
+	"help_Threads": {
+		"en": "The number of workers to walk directories and to scan files. 0 means the number of CPUs. 1 searches in order",
+		"ja": "ディレクトリの探索とファイルの走査をするワーカーの数。0 は CPU の数。1 は順番に検索する",
+	},
 	"help_SearchPath": {
 		"en": "A string to find paths",
 		"ja": "パスを検索するためのワード",
@@ -21,8 +35,16 @@ var message = map[string]map[string]string{
 		"ja": "コンテンツを検索するためのワード",
 	},
 	"help_SearchStart": {
-		"en": "A location to start searching",
-		"ja": "検索を開始するディレクトリパス",
+		"en": "A directory or a file to start searching. Glob patterns are expanded. '-' means standard input",
+		"ja": "検索を開始するディレクトリまたはファイルのパス。グロブパターンは展開される。'-' は標準入力",
+	},
+	"help_FilesFrom": {
+		"en": "Read the list of paths to search from this file instead of walking directories. '-' means standard input",
+		"ja": "ディレクトリを探索する代わりに、このファイルから検索対象のパスの一覧を読み込む。'-' は標準入力",
+	},
+	"help_NullData": {
+		"en": "Paths of --files-from are separated by \\0, rather than \\n",
+		"ja": "--files-from のパスが \\n の代わりに \\0 で区切られているものとして読む",
 	},
 	"help_IgnoreCase": {
 		"en": "Ignore case distinctions to search. Also affects keywords of ignore option",
@@ -32,6 +54,30 @@ var message = map[string]map[string]string{
 		"en": "Keep the order of result display",
 		"ja": "検索結果の順序を保持する",
 	},
+	"help_Deterministic": {
+		"en": "Show the same results in the same order on each run for the same tree. Same as --ordered-stream, but it also works with --keep-result-order and --sort",
+		"ja": "同じツリーなら毎回同じ結果を同じ順序で表示する。--ordered-stream と同じだが、--keep-result-order や --sort と併用しても有効",
+	},
+	"help_Sort": {
+		"en": "Sort results in ascending order by: " + supportSortKeys + ". It implies --keep-result-order",
+		"ja": "結果を昇順に並べ替える: " + supportSortKeys + "。--keep-result-order も有効になる",
+	},
+	"help_SortR": {
+		"en": "Sort results in descending order. The same keys as --sort",
+		"ja": "結果を降順に並べ替える。キーは --sort と同じ",
+	},
+	"help_SortNatural": {
+		"en": "Sort numbers in paths and names naturally like 'file2' before 'file10'",
+		"ja": "'file2' を 'file10' より前にするように、パスや名前の中の数字を自然な順序で並べ替える",
+	},
+	"help_DirsFirst": {
+		"en": "Sort directories before files",
+		"ja": "ディレクトリをファイルより前に並べる",
+	},
+	"help_OrderedStream": {
+		"en": "Show results as soon as they are found in the order of walking. It overrides --keep-result-order",
+		"ja": "探索した順序のまま、見つかった結果をすぐに表示する。--keep-result-order より優先される",
+	},
 	"help_SearchPathRe": {
 		"en": "A string to find paths by regular expressions (RE2)",
 		"ja": "パスを検索するための正規表現 (RE2)",
@@ -44,6 +90,10 @@ var message = map[string]map[string]string{
 		"en": "Not care about word boundary to match by regexp",
 		"ja": "正規表現でマッチする際に文字境界を無視してマッチするようにする",
 	},
+	"help_Lines": {
+		"en": "Only match lines in ranges like '100:200', '-50:' (last 50 lines) or '1:20,100:200'",
+		"ja": "指定した範囲の行だけマッチする。例: '100:200', '-50:' (最後の50行), '1:20,100:200'",
+	},
 	"help_ContextLines": {
 		"en": "Show several lines before and after the matched one",
 		"ja": "マッチした行の前後 n 行も表示する",
@@ -68,6 +118,18 @@ var message = map[string]map[string]string{
 		"en": "Search files and directories even if a path matches a line of .xfgignore",
 		"ja": ".xfgignore にマッチしたファイルやディレクトリも検索対象とする",
 	},
+	"help_SkipDotIgnore": {
+		"en": "Search files and directories even if a path matches a line of .ignore",
+		"ja": ".ignore にマッチしたファイルやディレクトリも検索対象とする",
+	},
+	"help_SkipRgIgnore": {
+		"en": "Search files and directories even if a path matches a line of .rgignore",
+		"ja": ".rgignore にマッチしたファイルやディレクトリも検索対象とする",
+	},
+	"help_SkipFdIgnore": {
+		"en": "Search files and directories even if a path matches a line of .fdignore",
+		"ja": ".fdignore にマッチしたファイルやディレクトリも検索対象とする",
+	},
 	"help_NoDefaultSkip": {
 		"en": "Not skip .git, .gitkeep, .gitkeep, .svn, node_modules, vendor, *.min.js and *.mmin.css",
 		"ja": "次のファイルやディレクトリも検索対象とする .git, .gitkeep, .gitkeep, .svn, node_modules, vendor, *.min.js and *.mmin.css",
@@ -92,18 +154,74 @@ var message = map[string]map[string]string{
 		"en": "Search to only name instead whole path string",
 		"ja": "パス全体ではなく、ファイルまたはディレクトリの名前だけを検索対象とする",
 	},
+	"help_Follow": {
+		"en": "Follow symbolic links",
+		"ja": "シンボリックリンクをたどる",
+	},
+	"help_OneFileSystem": {
+		"en": "Do not descend into directories on other file systems than the start directory",
+		"ja": "開始ディレクトリと異なるファイルシステムのディレクトリには降りていかない",
+	},
 	"help_Type": {
-		"en": "Filter by file type: " + supportTypes,
-		"ja": "ファイルタイプでフィルタする " + supportTypes,
+		"en": "Filter by file type: " + supportTypes + ". Multiple types like 'f,l'. Negate by '!' like '!d'",
+		"ja": "ファイルタイプでフィルタする " + supportTypes + "。'f,l' のように複数指定できる。'!d' のように '!' で否定できる",
+	},
+	"help_Owner": {
+		"en": "Filter by owner like 'user', 'user:group' or ':group'. Names or IDs. Not supported on Windows",
+		"ja": "所有者でフィルタする。例: 'user', 'user:group', ':group'。名前またはID。Windows は非対応",
+	},
+	"help_Perm": {
+		"en": "Filter by permission like '0644' (exactly), '-0600' (all of bits) or '/o+w' (any of bits)",
+		"ja": "パーミッションでフィルタする。例: '0644' (完全一致), '-0600' (すべてのビット), '/o+w' (いずれかのビット)",
+	},
+	"help_Setuid": {
+		"en": "Filter by setuid bit",
+		"ja": "setuid ビットが立っているものでフィルタする",
+	},
+	"help_Setgid": {
+		"en": "Filter by setgid bit",
+		"ja": "setgid ビットが立っているものでフィルタする",
 	},
 	"help_Ext": {
 		"en": "Only search files matching file extension",
 		"ja": "ファイル拡張子がマッチしたものだけ検索する",
 	},
+	"help_Size": {
+		"en": "Only search files matching size like '+1M' (at least), '-10k' (at most) or '512' (exactly)",
+		"ja": "ファイルサイズで絞り込む。例: '+1M' (以上), '-10k' (以下), '512' (ちょうど)",
+	},
+	"help_ChangedWithin": {
+		"en": "Only search paths modified within the duration like '2d' or after the date like '2026-01-01'",
+		"ja": "指定した期間内 (例: '2d') または日時 (例: '2026-01-01') 以降に更新されたパスだけ検索する",
+	},
+	"help_ChangedBefore": {
+		"en": "Only search paths modified before the duration like '2d' or the date like '2026-01-01'",
+		"ja": "指定した期間 (例: '2d') より前、または日時 (例: '2026-01-01') より前に更新されたパスだけ検索する",
+	},
+	"help_Newer": {
+		"en": "Only search paths modified more recently than this file",
+		"ja": "指定したファイルより後に更新されたパスだけ検索する",
+	},
+	"help_Older": {
+		"en": "Only search paths modified before this file",
+		"ja": "指定したファイルより前に更新されたパスだけ検索する",
+	},
 	"help_Lang": {
 		"en": "Only search files matching language. --lang-list prints all support languages",
 		"ja": "プログラミング言語を指定して検索する。--lang-list でサポートしている言語が一覧できる",
 	},
+	"help_LineEnding": {
+		"en": "Only search files by line endings: lf, crlf or mixed",
+		"ja": "改行コードでファイルを絞り込む: lf, crlf または mixed",
+	},
+	"help_CRLF": {
+		"en": "Only search files which have CRLF line endings. The alias of '--line-ending crlf --line-ending mixed'",
+		"ja": "改行コードに CRLF を含むファイルだけ検索する。'--line-ending crlf --line-ending mixed' のエイリアス",
+	},
+	"help_flagDebugIgnore": {
+		"en": "Explain which rule of ignore files decides whether the path is ignored or not, instead of searching",
+		"ja": "検索する代わりに、パスが無視されるかどうかを決める ignore ファイルのルールを表示する",
+	},
 	"help_flagLangList": {
 		"en": "Show all supported file extensions for each language",
 		"ja": "--lang で指定できる言語の一覧",
@@ -128,6 +246,26 @@ var message = map[string]map[string]string{
 		"en": "Maximum depth of directories to search",
 		"ja": "探索するディレクトリの深さ",
 	},
+	"help_MaxLineBytes": {
+		"en": "Truncate lines longer than this limit in bytes to match. 0 means no limit",
+		"ja": "1行の長さが指定したバイト数を超える場合、超えた部分を切り捨ててマッチする。0 は無制限",
+	},
+	"help_SkipLongLineFile": {
+		"en": "Skip a file which has a line longer than --max-line-bytes, instead of truncating the line",
+		"ja": "--max-line-bytes を超える行があるファイルは、行を切り捨てる代わりにスキップする",
+	},
+	"help_MaxFilesize": {
+		"en": "Do not search contents of files larger than this size like '10M'",
+		"ja": "指定したサイズ (例: '10M') より大きいファイルのコンテンツは検索しない",
+	},
+	"help_MinDepth": {
+		"en": "Minimum depth of paths to pick up. Shallower directories are still searched",
+		"ja": "結果として表示するパスの最小の深さ。浅いディレクトリも探索はする",
+	},
+	"help_ShowDepth": {
+		"en": "Show the depth of each path like '3:path'",
+		"ja": "'3:path' のように各パスの深さを表示する",
+	},
 	"help_FilesWithMatches": {
 		"en": "Print only the paths with at least one match",
 		"ja": "マッチするファイルパスのみを表示する。ディレクトリパスやマッチしたコンテンツ自体は表示しない",
@@ -136,6 +274,14 @@ var message = map[string]map[string]string{
 		"en": "Separate the filenames with \\0, rather than \\n",
 		"ja": "結果表示でファイル群を \\n の代わりに \\0 で分割する",
 	},
+	"help_Mmap": {
+		"en": "Always read files by mapping them into memory. Only files larger than 64MiB are mapped by default",
+		"ja": "常にファイルをメモリにマップして読む。デフォルトでは 64MiB より大きなファイルのみマップする",
+	},
+	"help_NoMmap": {
+		"en": "Never map files into memory to read them. Use it for files which may be truncated while searching",
+		"ja": "ファイルをメモリにマップして読まない。検索中に切り詰められるかもしれないファイルにはこれを使う",
+	},
 	"help_NoColor": {
 		"en": "Disable colors for an output",
 		"ja": "結果表示に色を付けない",
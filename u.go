@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -54,20 +56,49 @@ func readRC(homeDir string) (*options, error) {
 	return o, nil
 }
 
-func isBinaryFile(fh *os.File) (bool, error) {
-	dat := make([]byte, 8000)
-	n, err := fh.Read(dat)
-	if err != nil {
-		return false, fmt.Errorf("could not read fh : %w", err)
+// countLines counts lines including the last line without a line break
+func countLines(r io.Reader) (int32, error) {
+	var count int32
+	var last byte
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			count = count + int32(bytes.Count(buf[:n], []byte("\n")))
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+	}
+
+	if last != 0 && last != '\n' {
+		count++
+	}
+
+	return count, nil
+}
+
+// countLinesInBytes counts lines like countLines does
+func countLinesInBytes(dat []byte) int32 {
+	count := int32(bytes.Count(dat, []byte("\n")))
+	if len(dat) > 0 && dat[len(dat)-1] != '\n' {
+		count++
 	}
 
-	for _, c := range dat[:n] {
+	return count
+}
+
+func isBinary(dat []byte) bool {
+	for _, c := range dat {
 		if c == 0x00 {
-			return true, nil
+			return true
 		}
 	}
 
-	return false, nil
+	return false
 }
 
 func isRegularFile(fInfo fs.DirEntry) bool {
@@ -81,22 +112,30 @@ func isRegularFile(fInfo fs.DirEntry) bool {
 
 func validateStartPath(startPaths []string) error {
 	for i, sp := range startPaths {
+		if sp == stdinStartPath {
+			continue
+		}
 		sp = filepath.Clean(sp)
-		d, err := os.Stat(sp)
-		if err != nil {
+		if _, err := os.Stat(sp); err != nil {
 			return err
 		}
 
-		if !d.IsDir() {
-			return fmt.Errorf("path `%s` should point to a directory", startPaths[i])
-		}
-
 		startPaths[i] = sp
 	}
 
 	return nil
 }
 
+func isStdinStart(startPaths []string) bool {
+	for _, sp := range startPaths {
+		if sp == stdinStartPath {
+			return true
+		}
+	}
+
+	return false
+}
+
 func validateLanguageCondition(lang []string) error {
 	for _, l := range lang {
 		if !xfglangxt.IsSupported(l) {
@@ -107,6 +146,16 @@ func validateLanguageCondition(lang []string) error {
 	return nil
 }
 
+func validateLineEnding(lineEndings []string) error {
+	for _, le := range lineEndings {
+		if le != lineEndingLF && le != lineEndingCRLF && le != lineEndingMixed {
+			return fmt.Errorf("wrong line ending `%s`. Supported: %s, %s, %s", le, lineEndingLF, lineEndingCRLF, lineEndingMixed)
+		}
+	}
+
+	return nil
+}
+
 func isMatch(target string, included string) bool {
 	if target == "" || included == "" {
 		return false
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	a "github.com/bayashi/actually"
@@ -35,7 +36,31 @@ func TestReadRC(t *testing.T) {
 	a.Got(o.Abs).True(t)
 }
 
-func TestValidateStartPath_Err(t *testing.T) {
+func TestReadRC_Type(t *testing.T) {
+	for rc, expect := range map[string][]string{
+		`Type = "d"`:         {"d"},
+		`type = "f,l"`:       {"f,l"},
+		`type = ["f", "!e"]`: {"f", "!e"},
+		`Type = []`:          {},
+		`abs = true`:         nil,
+	} {
+		rcFilePath := filepath.Join(t.TempDir(), "test.toml")
+		a.Got(os.WriteFile(rcFilePath, []byte(rc), 0644)).NoError(t)
+		t.Setenv(XFG_RC_ENV_KEY, rcFilePath)
+
+		o, err := readRC("fake")
+		a.Got(err).Debug("rc", rc).NoError(t)
+		a.Got([]string(o.Type)).Expect(expect).Debug("rc", rc).Same(t)
+	}
+
+	rcFilePath := filepath.Join(t.TempDir(), "test.toml")
+	a.Got(os.WriteFile(rcFilePath, []byte(`type = 1`), 0644)).NoError(t)
+	t.Setenv(XFG_RC_ENV_KEY, rcFilePath)
+	_, err := readRC("fake")
+	a.Got(err).Expect("expected a string or an array of strings").Match(t)
+}
+
+func TestValidateStartPath(t *testing.T) {
 	t.Parallel()
 	err := validateStartPath([]string{noMatchKeyword})
 	a.Got(err).NotNil(t)
@@ -50,6 +75,27 @@ func TestValidateStartPath_Err(t *testing.T) {
 	f.Close()
 
 	err = validateStartPath([]string{tempFilePath})
-	a.Got(err).NotNil(t)
-	a.Got(err.Error()).Expect("path `[^`]+` should point to a directory").Match(t)
+	a.Got(err).NoError(t)
+}
+
+func TestValidateLineEnding(t *testing.T) {
+	t.Parallel()
+	a.Got(validateLineEnding([]string{"lf", "crlf", "mixed"})).NoError(t)
+	a.Got(validateLineEnding([]string{"cr"})).Expect("wrong line ending `cr`").Match(t)
+}
+
+func TestCountLines(t *testing.T) {
+	t.Parallel()
+	for in, expect := range map[string]int32{
+		"":           0,
+		"foo":        1,
+		"foo\n":      1,
+		"foo\nbar":   2,
+		"foo\n\n\n":  3,
+		"a\r\nb\r\n": 2,
+	} {
+		lc, err := countLines(strings.NewReader(in))
+		a.Got(err).NoError(t)
+		a.Got(lc).Expect(expect).Same(t)
+	}
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"io/fs"
 	"regexp"
 	"sync"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -18,13 +20,17 @@ type path struct {
 	path     string
 	info     fs.DirEntry
 	contents []line
+	depth    uint32 // depth from the start directory. Entries in the start directory are 1
+	seq      uint64 // the order of walking for --ordered-stream
 }
 
 type result struct {
 	mu                  sync.RWMutex
-	paths               []path
+	paths               *resultStore // only for ordered output. Streaming output keeps counters only
+	pickedCount         int
 	outputLC            int // Used on pager. Rough count. Not included group separators.
 	alreadyMatchContent bool
+	resultCount         int // the count of results for --max-results
 }
 
 type highlighter struct {
@@ -40,7 +46,18 @@ type xfgExtra struct {
 	searchGrepi    []*regexp.Regexp
 	searchPathRe   []*regexp.Regexp
 	searchGrepRe   []*regexp.Regexp
+	contentMatcher *contentMatcher
 	ignoreOptionRe []*regexp.Regexp
+	dirIgnoreFiles []string // names of ignore files in each directory
+	lineRanges     []lineRange
+	needTotalLC    bool  // need to count all lines before scanning, to resolve negative line ranges
+	tailLC         int32 // read only the last lines for ranges from the end like `-50:`
+	sizeConditions []sizeCondition
+	maxFilesize    int64
+	changedAfter   time.Time
+	changedBefore  time.Time
+	ownerCondition *ownerCondition
+	permConditions []permCondition
 }
 
 type xfg struct {
@@ -51,11 +68,14 @@ type xfg struct {
 	result      result
 	resultChan  chan path // Channel for streaming results when KeepResultOrder is false
 	streamDone  chan bool // Channel to signal streaming display goroutine completion
+	cancel      context.CancelCauseFunc
+	ordered     *orderedStream // reorder buffer for --ordered-stream
 }
 
 func newX(cli *runner, o *options) *xfg {
 	o.prepareFromENV()
 	o.prepareAliases()
+	o.Type = splitTypes(o.Type)
 	o.prepareContextLines(cli.isTTY)
 	o.prepareRuntimeFlags()
 
@@ -63,6 +83,8 @@ func newX(cli *runner, o *options) *xfg {
 		cli:     cli,
 		options: o,
 	}
+	x.result.paths = newResultStore(spillResultThresholdBytes)
+	x.result.paths.less = x.sortLess()
 
 	return x
 }
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+)
+
+var (
+	errInterrupted = errors.New("interrupted")
+	errTimeout     = errors.New("timed out")
+	errMaxResults  = errors.New("reached max results")
+	errQuietMatch  = errors.New("matched on quiet")
+)
+
+// searchContext returns the context to stop searching by SIGINT, --timeout, --max-results or -q.
+// The first SIGINT stops searching to show partial results, then the next one kills the process as usual.
+func (x *xfg) searchContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	x.cancel = cancel
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		select {
+		case <-sig:
+			cancel(errInterrupted)
+		case <-ctx.Done():
+		}
+		signal.Stop(sig)
+	}()
+
+	if x.options.Timeout <= 0 {
+		return ctx, func() { cancel(context.Canceled) }
+	}
+
+	timeoutCtx, timeoutCancel := context.WithTimeoutCause(ctx, x.options.Timeout, errTimeout)
+
+	return timeoutCtx, func() {
+		timeoutCancel()
+		cancel(context.Canceled)
+	}
+}
+
+// stop stops whole searching
+func (x *xfg) stop(cause error) {
+	if x.cancel != nil {
+		x.cancel(cause)
+	}
+}
+
+// isDone is cheaper than ctx.Err() to check on each line
+func isDone(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// stoppedReason returns the message for a user if searching was stopped halfway
+func stoppedReason(ctx context.Context) string {
+	switch cause := context.Cause(ctx); {
+	case errors.Is(cause, errInterrupted):
+		return "interrupted. results are partial"
+	case errors.Is(cause, errTimeout):
+		return "timed out. results are partial"
+	}
+
+	return ""
+}
+
+// limitResults cuts the matched path to fit the remaining count of --max-results.
+// The unit of results is a matched line on content search, otherwise a path.
+func (x *xfg) limitResults(matchedPath path, remaining int) (path, int) {
+	if !x.options.extra.onlyMatchContent || x.options.FilesWithMatches {
+		return matchedPath, 1
+	}
+
+	count := 0
+	for i, l := range matchedPath.contents {
+		if !l.matched {
+			continue
+		}
+		count++
+		if count == remaining {
+			matchedPath.contents = matchedPath.contents[:i+1]
+			break
+		}
+	}
+
+	return matchedPath, count
+}
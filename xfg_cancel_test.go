@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestLimitResults(t *testing.T) {
+	t.Parallel()
+	contents := []line{
+		{lc: 1, content: "foo", matched: true},
+		{lc: 2, content: "bar"},
+		{lc: 3, content: "foo", matched: true},
+		{lc: 4, content: "baz"},
+	}
+
+	x := &xfg{options: &options{}}
+	x.options.extra.onlyMatchContent = true
+
+	p, count := x.limitResults(path{contents: contents}, 1)
+	a.Got(count).Expect(1).Same(t)
+	a.Got(p.contents).Expect(contents[:1]).Same(t)
+
+	p, count = x.limitResults(path{contents: contents}, 5)
+	a.Got(count).Expect(2).Same(t)
+	a.Got(p.contents).Expect(contents).Same(t)
+
+	x.options.FilesWithMatches = true
+	p, count = x.limitResults(path{contents: contents}, 1)
+	a.Got(count).Expect(1).Same(t)
+	a.Got(p.contents).Expect(contents).Same(t)
+}
+
+func TestStoppedReason(t *testing.T) {
+	t.Parallel()
+	for cause, expect := range map[error]string{
+		errInterrupted:   "interrupted. results are partial",
+		errTimeout:       "timed out. results are partial",
+		errMaxResults:    "",
+		errQuietMatch:    "",
+		context.Canceled: "",
+	} {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(cause)
+		a.Got(stoppedReason(ctx)).Expect(expect).Same(t)
+	}
+
+	a.Got(stoppedReason(context.Background())).Expect("").Same(t)
+}
+
+func TestSearchContext(t *testing.T) {
+	t.Parallel()
+	x := &xfg{options: &options{}}
+	ctx, cancel := x.searchContext(context.Background())
+	a.Got(isDone(ctx)).False(t)
+
+	x.stop(errMaxResults)
+	a.Got(isDone(ctx)).True(t)
+	a.Got(context.Cause(ctx)).Expect(errMaxResults).Same(t)
+	cancel()
+}
+
+func TestSearchContext_Timeout(t *testing.T) {
+	t.Parallel()
+	x := &xfg{options: &options{Timeout: time.Millisecond}}
+	ctx, cancel := x.searchContext(context.Background())
+	defer cancel()
+
+	<-ctx.Done()
+	a.Got(context.Cause(ctx)).Expect(errTimeout).Same(t)
+	a.Got(stoppedReason(ctx)).Expect("timed out. results are partial").Same(t)
+}
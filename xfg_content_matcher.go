@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"regexp/syntax"
+)
+
+// contentMatcher matches a line of contents in bytes, without converting it to a string.
+// Keywords to ignore case are matched by folding ASCII letters if possible, instead of regexps.
+// prefilter is a literal which must be in all matched lines. It is used to jump to candidate lines in a block.
+type contentMatcher struct {
+	keywords     [][]byte
+	foldKeywords [][]byte         // keywords in lower case to match ignoring ASCII case
+	foldRegexps  []*regexp.Regexp // keywords to ignore case which can not be folded as ASCII
+	regexps      []*regexp.Regexp
+
+	prefilter     []byte // nil means no prefilter
+	prefilterFold bool   // prefilter is in lower case to match ignoring ASCII case
+}
+
+func newContentMatcher(o *options, searchGrepi []*regexp.Regexp, searchGrepRe []*regexp.Regexp) *contentMatcher {
+	m := &contentMatcher{regexps: searchGrepRe}
+
+	for i, kw := range o.SearchGrep {
+		if !o.IgnoreCase {
+			m.keywords = append(m.keywords, []byte(kw))
+		} else if canFoldASCII(kw) {
+			m.foldKeywords = append(m.foldKeywords, toLowerASCII([]byte(kw)))
+		} else if i < len(searchGrepi) {
+			m.foldRegexps = append(m.foldRegexps, searchGrepi[i])
+		}
+	}
+
+	m.setPrefilter()
+
+	return m
+}
+
+// setPrefilter picks the longest literal as the rarest one. Literals in case-sensitive are preferred
+func (m *contentMatcher) setPrefilter() {
+	var literals [][]byte
+	literals = append(literals, m.keywords...)
+	for _, re := range m.regexps {
+		if sre, err := syntax.Parse(re.String(), syntax.Perl); err == nil {
+			literals = append(literals, requiredLiteral(sre))
+		}
+	}
+
+	m.prefilter = longestLiteral(literals)
+	if m.prefilter == nil {
+		m.prefilter = longestLiteral(m.foldKeywords)
+		m.prefilterFold = m.prefilter != nil
+	}
+}
+
+func longestLiteral(literals [][]byte) []byte {
+	var longest []byte
+	for _, l := range literals {
+		if len(l) > len(longest) && bytes.IndexByte(l, '\n') < 0 {
+			longest = l
+		}
+	}
+
+	return longest
+}
+
+// requiredLiteral returns a literal which must be in any text matched by the regexp, or nil
+func requiredLiteral(re *syntax.Regexp) []byte {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil
+		}
+		return []byte(string(re.Rune))
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiteral(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiteral(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var literals [][]byte
+		for _, sub := range re.Sub {
+			literals = append(literals, requiredLiteral(sub))
+		}
+		return longestLiteral(literals)
+	}
+
+	return nil
+}
+
+// match returns true if the line matches all keywords and regexps
+func (m *contentMatcher) match(l []byte) bool {
+	if len(l) == 0 {
+		return false
+	}
+
+	for _, kw := range m.keywords {
+		if len(kw) == 0 || !bytes.Contains(l, kw) {
+			return false
+		}
+	}
+
+	for _, kw := range m.foldKeywords {
+		if indexFoldASCII(l, kw) < 0 {
+			return false
+		}
+	}
+
+	for _, re := range m.foldRegexps {
+		if !re.Match(l) {
+			return false
+		}
+	}
+
+	for _, re := range m.regexps {
+		if !re.Match(l) {
+			return false
+		}
+	}
+
+	return true // OK, match all
+}
+
+// indexPrefilter returns the index of the prefilter in the block, or -1
+func (m *contentMatcher) indexPrefilter(block []byte) int {
+	if m.prefilterFold {
+		return indexFoldASCII(block, m.prefilter)
+	}
+
+	return bytes.Index(block, m.prefilter)
+}
+
+// canFoldASCII returns true if folding ASCII letters is the same as the simple case folding of Unicode.
+// `k` and `s` are also folded with non-ASCII letters, KELVIN SIGN and LATIN SMALL LETTER LONG S.
+func canFoldASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i] | 0x20
+		if s[i] >= 0x80 || c == 'k' || c == 's' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func toLowerASCII(b []byte) []byte {
+	lower := make([]byte, len(b))
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			c = c + 'a' - 'A'
+		}
+		lower[i] = c
+	}
+
+	return lower
+}
+
+// indexFoldASCII returns the index of lower in s ignoring ASCII case, or -1. lower must be in lower case
+func indexFoldASCII(s []byte, lower []byte) int {
+	if len(lower) == 0 {
+		return 0
+	}
+
+	limit := len(s) - len(lower) + 1
+	if limit <= 0 {
+		return -1
+	}
+
+	first := lower[0]
+	upper := first
+	if 'a' <= first && first <= 'z' {
+		upper = first - 'a' + 'A'
+	}
+	next := func(c byte, from int) int {
+		if j := bytes.IndexByte(s[from:limit], c); j >= 0 {
+			return from + j
+		}
+		return limit
+	}
+
+	// the next candidates of the first letter in lower case and upper case
+	nl := next(first, 0)
+	nu := nl
+	if upper != first {
+		nu = next(upper, 0)
+	}
+	for {
+		i := min(nl, nu)
+		if i >= limit {
+			return -1
+		}
+		if equalFoldASCII(s[i:i+len(lower)], lower) {
+			return i
+		}
+		if nl == i {
+			nl = next(first, i+1)
+		}
+		if upper == first {
+			nu = nl
+		} else if nu == i {
+			nu = next(upper, i+1)
+		}
+	}
+}
+
+func equalFoldASCII(s []byte, lower []byte) bool {
+	for i, c := range s {
+		if 'A' <= c && c <= 'Z' {
+			c = c + 'a' - 'A'
+		}
+		if c != lower[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestContentMatcher_Prefilter(t *testing.T) {
+	t.Parallel()
+	for tname, tt := range map[string]struct {
+		opt          *options
+		expect       string
+		expectFold   bool
+		expectNoPref bool
+	}{
+		"the longest keyword": {
+			opt:    &options{SearchGrep: []string{"foo", "foobar"}},
+			expect: "foobar",
+		},
+		"literal in regexp": {
+			opt:    &options{SearchGrepRe: []string{`func\s+[a-z]+Handler\(`}},
+			expect: "Handler(",
+		},
+		"literal prefix of regexp": {
+			opt:    &options{SearchGrepRe: []string{`import.*`}},
+			expect: "import",
+		},
+		"case-sensitive is preferred": {
+			opt:    &options{SearchGrep: []string{"foobar"}, SearchGrepRe: []string{`ab`}, IgnoreCase: true},
+			expect: "ab",
+		},
+		"ignore case": {
+			opt:        &options{SearchGrep: []string{"FooBar"}, IgnoreCase: true},
+			expect:     "foobar",
+			expectFold: true,
+		},
+		"ignore case with non ASCII folding": {
+			opt:          &options{SearchGrep: []string{"Kelvin"}, IgnoreCase: true},
+			expectNoPref: true,
+		},
+		"alternation": {
+			opt:          &options{SearchGrepRe: []string{`foo|bar`}},
+			expectNoPref: true,
+		},
+		"optional": {
+			opt:    &options{SearchGrepRe: []string{`(foo)?bar*`}},
+			expect: "ba",
+		},
+	} {
+		tt := tt
+		t.Run(tname, func(t *testing.T) {
+			t.Parallel()
+			x := &xfg{options: tt.opt}
+			a.Got(x.preWalkDir()).NoError(t)
+			m := x.extra.contentMatcher
+			if tt.expectNoPref {
+				a.Got(m.prefilter).Nil(t)
+				return
+			}
+			a.Got(string(m.prefilter)).Expect(tt.expect).Same(t)
+			a.Got(m.prefilterFold).Expect(tt.expectFold).Same(t)
+		})
+	}
+}
+
+func TestContentMatcher_Match(t *testing.T) {
+	t.Parallel()
+	for tname, tt := range map[string]struct {
+		opt    *options
+		line   string
+		expect bool
+	}{
+		"keyword":               {opt: &options{SearchGrep: []string{"foo"}}, line: "a foo b", expect: true},
+		"case-sensitive":        {opt: &options{SearchGrep: []string{"foo"}}, line: "a FOO b", expect: false},
+		"all keywords":          {opt: &options{SearchGrep: []string{"foo", "bar"}}, line: "foo", expect: false},
+		"empty line":            {opt: &options{SearchGrep: []string{"foo"}}, line: "", expect: false},
+		"empty keyword":         {opt: &options{SearchGrep: []string{""}}, line: "foo", expect: false},
+		"ignore case":           {opt: &options{SearchGrep: []string{"FoO"}, IgnoreCase: true}, line: "a fOo b", expect: true},
+		"ignore case not match": {opt: &options{SearchGrep: []string{"foo"}, IgnoreCase: true}, line: "a fo b", expect: false},
+		"KELVIN SIGN":           {opt: &options{SearchGrep: []string{"kelvin"}, IgnoreCase: true}, line: "Kelvin", expect: true},
+		"regexp":                {opt: &options{SearchGrepRe: []string{`fo+`}}, line: "a foooo b", expect: true},
+		"regexp word boundary":  {opt: &options{SearchGrepRe: []string{`fo+`}}, line: "afoooo b", expect: false},
+		"keyword and regexp":    {opt: &options{SearchGrep: []string{"a"}, SearchGrepRe: []string{`fo+`}}, line: "a foo", expect: true},
+	} {
+		tt := tt
+		t.Run(tname, func(t *testing.T) {
+			t.Parallel()
+			x := &xfg{options: tt.opt}
+			a.Got(x.preWalkDir()).NoError(t)
+			a.Got(x.extra.contentMatcher.match([]byte(tt.line))).Expect(tt.expect).Same(t)
+		})
+	}
+}
+
+func TestIndexFoldASCII(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		s      string
+		lower  string
+		expect int
+	}{
+		{s: "xxFOOxx", lower: "foo", expect: 2},
+		{s: "FfFfOo", lower: "foo", expect: 3},
+		{s: "fo", lower: "foo", expect: -1},
+		{s: "AAAAAAAAAAb", lower: "ab", expect: 9},
+		{s: "", lower: "", expect: 0},
+		{s: "a-b", lower: "-b", expect: 1},
+	} {
+		a.Got(indexFoldASCII([]byte(tt.s), []byte(tt.lower))).Expect(tt.expect).X().Debug("s", tt.s).Same(t)
+	}
+}
+
+func TestScanContent_BlocksSameAsLines(t *testing.T) {
+	t.Parallel()
+	var sb strings.Builder
+	for i := 0; i < 20000; i++ {
+		fmt.Fprintf(&sb, "line %d foo%d Bar\r\n", i, i%7)
+		if i == 10000 {
+			sb.WriteString(strings.Repeat("x", scanBlockBytes*2) + " foo3\n") // longer than a block
+		}
+	}
+	sb.WriteString("the last foo3") // without a line break
+	content := sb.String()
+
+	for tname, opt := range map[string]*options{
+		"keyword":          {SearchGrep: []string{"foo3"}},
+		"ignore case":      {SearchGrep: []string{"FOO3 bar"}, IgnoreCase: true},
+		"regexp":           {SearchGrepRe: []string{`1\d+ foo[25]`}},
+		"max line bytes":   {SearchGrep: []string{"foo3"}, MaxLineBytes: 12},
+		"max columns":      {SearchGrep: []string{"foo3"}, MaxColumns: 5},
+		"line ranges":      {SearchGrep: []string{"foo3"}, Lines: []string{"100:200,15000:15100"}},
+		"max count":        {SearchGrep: []string{"foo3"}, MaxMatchCount: 3},
+		"match count":      {SearchGrep: []string{"foo3"}, ShowMatchCount: true},
+		"files with match": {SearchGrep: []string{"foo3"}, FilesWithMatches: true},
+	} {
+		opt := opt
+		t.Run(tname, func(t *testing.T) {
+			t.Parallel()
+			x := &xfg{options: opt}
+			a.Got(x.preWalkDir()).NoError(t)
+			a.Got(x.canScanBlocks()).True(t)
+			blocks, err := x.scanContent(context.Background(), bufio.NewReader(strings.NewReader(content)), "", 0, 0)
+			a.Got(err).NoError(t)
+			a.Got(len(blocks) > 0).True(t)
+
+			x.extra.contentMatcher.prefilter = nil
+			a.Got(x.canScanBlocks()).False(t)
+			lines, err := x.scanContent(context.Background(), bufio.NewReader(strings.NewReader(content)), "", 0, 0)
+			a.Got(err).NoError(t)
+			a.Got(blocks).Expect(lines).Same(t)
+		})
+	}
+}
+
+// BenchmarkScanContent_SmallFiles scans many small files like a source tree. A buffer per file must not be allocated
+func BenchmarkScanContent_SmallFiles(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&sb, "func f%d() { return bar%d }\n", i, i)
+	}
+	content := sb.String()
+	x := &xfg{options: &options{SearchGrep: []string{"zzqqxx"}}}
+	if err := x.preWalkDir(); err != nil {
+		b.Fatal(err)
+	}
+	reader := bufio.NewReader(nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(strings.NewReader(content))
+		if _, err := x.scanContent(context.Background(), reader, "", 0, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bayashi/xfg/internal/xfgignore"
+)
+
+// debugIgnore explains which rule of ignore files decides whether the path is ignored or not,
+// by evaluating rules from the start directory to the path like walking does
+func (x *xfg) debugIgnore(out io.Writer, target string) error {
+	target = filepath.Clean(target)
+	fi, err := os.Lstat(target)
+	if err != nil {
+		return err
+	}
+
+	start := filepath.Dir(target)
+	for _, s := range x.searchStartDirs() {
+		if s == stdinStartPath {
+			continue
+		}
+		if rel, err := filepath.Rel(s, target); err == nil && rel != "." && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			start = filepath.Clean(s)
+			break
+		}
+	}
+
+	rel, err := filepath.Rel(start, target)
+	if err != nil {
+		return err
+	}
+
+	x.extra.dirIgnoreFiles = x.dirIgnoreFiles()
+	im := x.initIgnoreMatchers(start)
+	dir := start
+	elements := strings.Split(rel, string(filepath.Separator))
+	for i, e := range elements {
+		im = x.withDirIgnoreFiles(im, dir, func(string) bool { return true })
+		p := filepath.Join(dir, e)
+		if i < len(elements)-1 {
+			if r := im.Explain(p, true); r != nil && !r.Negate() {
+				fmt.Fprintf(out, "`%s` is ignored, because `%s` is ignored by %s\n", target, p, explainRule(r))
+				return nil
+			}
+			dir = p
+			continue
+		}
+
+		r := im.Explain(p, fi.IsDir())
+		if r == nil {
+			fmt.Fprintf(out, "`%s` is not ignored by any rule\n", target)
+		} else if r.Negate() {
+			fmt.Fprintf(out, "`%s` is not ignored, because it is re-included by %s\n", target, explainRule(r))
+		} else {
+			fmt.Fprintf(out, "`%s` is ignored by %s\n", target, explainRule(r))
+		}
+	}
+
+	return nil
+}
+
+func explainRule(r *xfgignore.Rule) string {
+	return fmt.Sprintf("`%s` at line %d of `%s`", r.Pattern, r.Line, r.Source)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bayashi/xfg/internal/xfgignore"
+)
+
+func (x *xfg) searchStartDirs() []string {
+	if x.options.FilesFrom != "" {
+		return nil // paths come from --files-from instead
+	}
+
+	return x.options.SearchStart
+}
+
+func (x *xfg) walkFilesFrom(ctx context.Context, wp *workerPool) error {
+	paths, err := x.readFilesFrom()
+	if err != nil {
+		return err
+	}
+
+	ignore := &filesFromIgnore{x: x, dirs: map[string]*ignoreDir{}}
+	for _, fPath := range paths {
+		if isDone(ctx) {
+			break // stopped. skip after all
+		}
+		fi, err := os.Lstat(fPath)
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				if !x.options.IgnorePermissionError {
+					x.cli.putErr(err)
+				}
+				continue
+			}
+			if errors.Is(err, fs.ErrNotExist) {
+				x.cli.putErr(err)
+				continue
+			}
+			return err
+		}
+		if !x.options.SearchAll && !x.options.SearchDefaultSkipStuff && ignore.isIgnored(fPath, fi.IsDir()) {
+			continue
+		}
+		x.walkFile(ctx, wp, fPath, fs.FileInfoToDirEntry(fi), nil, pathDepth(fPath), false)
+	}
+
+	return nil
+}
+
+// filesFromIgnore decides whether paths of --files-from are ignored or not.
+// Rules are read from the root of the repository of each path to the path, like walking does.
+type filesFromIgnore struct {
+	x    *xfg
+	dirs map[string]*ignoreDir // by the absolute path
+}
+
+type ignoreDir struct {
+	im      *xfgignore.Matcher // rules for paths in the directory
+	ignored bool               // the directory or its parent is ignored
+}
+
+func (fi *filesFromIgnore) isIgnored(fPath string, isDir bool) bool {
+	abs, err := filepath.Abs(fPath)
+	if err != nil {
+		return false // trap error
+	}
+
+	dirPath := filepath.Dir(abs)
+	d, ok := fi.dirs[dirPath]
+	if !ok {
+		d = fi.dir(dirPath, ignoreTopDir(dirPath))
+	}
+
+	return d.ignored || fi.x.isSkippableByIgnoreFile(abs, isDir, d.im)
+}
+
+func (fi *filesFromIgnore) dir(dirPath string, top string) *ignoreDir {
+	if d, ok := fi.dirs[dirPath]; ok {
+		return d
+	}
+
+	var d *ignoreDir
+	if parent := filepath.Dir(dirPath); dirPath == top || parent == dirPath {
+		d = &ignoreDir{im: fi.x.initIgnoreMatchers(dirPath)}
+	} else {
+		p := fi.dir(parent, top)
+		d = &ignoreDir{im: p.im, ignored: p.ignored || fi.x.isSkippableByIgnoreFile(dirPath, true, p.im)}
+	}
+	d.im = fi.x.withDirIgnoreFiles(d.im, dirPath, func(string) bool { return true })
+	fi.dirs[dirPath] = d
+
+	return d
+}
+
+// ignoreTopDir returns the root directory of the repository which has the absolute directory path,
+// in the same form as the path. It is the directory itself out of repositories.
+func ignoreTopDir(dirPath string) string {
+	repo := xfgignore.FindRepository(dirPath)
+	if repo == nil {
+		return dirPath
+	}
+	real, err := filepath.EvalSymlinks(dirPath)
+	if err != nil {
+		return dirPath
+	}
+	rel, err := filepath.Rel(repo.Root, real)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return dirPath
+	}
+
+	top := dirPath
+	for range strings.Split(rel, string(filepath.Separator)) {
+		top = filepath.Dir(top)
+	}
+
+	return top
+}
+
+// pathDepth is the count of elements of the path, as a depth from the current directory
+func pathDepth(fPath string) uint32 {
+	fPath = filepath.Clean(fPath)
+	if filepath.IsAbs(fPath) {
+		fPath = strings.TrimPrefix(fPath, filepath.VolumeName(fPath))
+		fPath = strings.TrimPrefix(fPath, string(filepath.Separator))
+	}
+
+	return uint32(strings.Count(fPath, string(filepath.Separator)) + 1)
+}
+
+func (x *xfg) readFilesFrom() ([]string, error) {
+	var r io.Reader
+	if x.options.FilesFrom == stdinStartPath {
+		if x.cli.in == nil {
+			return nil, nil
+		}
+		r = x.cli.in
+	} else {
+		fh, err := os.Open(x.options.FilesFrom)
+		if err != nil {
+			return nil, fmt.Errorf("could not open `%s` : %w", x.options.FilesFrom, err)
+		}
+		defer fh.Close()
+		r = fh
+	}
+
+	dat, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read `%s` : %w", x.options.FilesFrom, err)
+	}
+
+	sep := "\n"
+	if x.options.NullData {
+		sep = "\x00"
+	}
+
+	var paths []string
+	for _, p := range strings.Split(string(dat), sep) {
+		if !x.options.NullData {
+			p = strings.TrimSuffix(p, "\r")
+		}
+		if p == "" {
+			continue
+		}
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+func (x *xfg) startAncestors(startDir string) []fs.FileInfo {
+	if !x.options.Follow {
+		return nil
+	}
+
+	fi, err := os.Stat(startDir)
+	if err != nil {
+		return nil // trap error. validated already
+	}
+
+	return []fs.FileInfo{fi}
+}
+
+// followSymlink returns the entry of the target of the link.
+// Returns the link itself for a broken link, and warns it.
+func (x *xfg) followSymlink(fPath string, link fs.DirEntry) fs.DirEntry {
+	fi, err := os.Stat(fPath)
+	if err != nil {
+		x.cli.putErr(fmt.Sprintf("broken symbolic link: `%s` : %s", fPath, err))
+		return link
+	}
+
+	return fs.FileInfoToDirEntry(fi)
+}
+
+// isFileSystemLoop compares device and inode of the directory with its ancestors
+func isFileSystemLoop(fi fs.FileInfo, ancestors []fs.FileInfo) bool {
+	for _, a := range ancestors {
+		if os.SameFile(fi, a) {
+			return true
+		}
+	}
+
+	return false
+}
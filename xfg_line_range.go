@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// lineRange is an inclusive range of 1-based line numbers.
+// A negative number means the line from the end. e.g. -1 is the last line
+type lineRange struct {
+	start int32
+	end   int32
+}
+
+// parseLineRanges parses specs like "100:200", "-50:", ":20", "7" or "1:20,100:200"
+func parseLineRanges(specs []string) ([]lineRange, error) {
+	var ranges []lineRange
+	for _, spec := range specs {
+		for _, s := range strings.Split(spec, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+			lr, err := parseLineRange(s)
+			if err != nil {
+				return nil, err
+			}
+			ranges = append(ranges, lr)
+		}
+	}
+
+	return ranges, nil
+}
+
+func parseLineRange(s string) (lineRange, error) {
+	startStr, endStr, isRange := strings.Cut(s, ":")
+	if !isRange {
+		endStr = startStr
+	}
+
+	lr := lineRange{start: 1, end: math.MaxInt32}
+	if startStr != "" {
+		n, err := parseLineNumber(startStr)
+		if err != nil {
+			return lr, fmt.Errorf("wrong line range `%s` : %w", s, err)
+		}
+		lr.start = n
+	}
+	if endStr != "" {
+		n, err := parseLineNumber(endStr)
+		if err != nil {
+			return lr, fmt.Errorf("wrong line range `%s` : %w", s, err)
+		}
+		lr.end = n
+	}
+
+	return lr, nil
+}
+
+func parseLineNumber(s string) (int32, error) {
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("line number should not be 0")
+	}
+
+	return int32(n), nil
+}
+
+func hasNegativeLineRange(ranges []lineRange) bool {
+	for _, lr := range ranges {
+		if lr.start < 0 || lr.end < 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tailLineCount returns the count of the last lines which cover all ranges, if all ranges start from the end.
+// e.g. 50 for `-50:`. Then only the tail of a file is read. It returns 0 otherwise
+func tailLineCount(ranges []lineRange) int32 {
+	var n int32
+	for _, lr := range ranges {
+		if lr.start >= 0 {
+			return 0
+		}
+		n = max(n, -lr.start)
+	}
+
+	return n
+}
+
+// findTail reads backward from the end to find the offset where the last n lines start.
+// It returns the offset and the count of lines from there, which is less than n for a short content
+func findTail(r io.ReaderAt, size int64, n int32) (int64, int32, error) {
+	if size == 0 {
+		return 0, 0, nil
+	}
+
+	buf := make([]byte, min(size, scanBlockBytes))
+	pos := size
+	found := int32(0) // line breaks before the last line
+	for pos > 0 {
+		start := max(pos-int64(len(buf)), 0)
+		block := buf[:pos-start]
+		if _, err := r.ReadAt(block, start); err != nil && err != io.EOF {
+			return 0, 0, err
+		}
+		if pos == size && block[len(block)-1] == '\n' {
+			block = block[:len(block)-1] // the line break of the last line
+		}
+		for {
+			i := bytes.LastIndexByte(block, '\n')
+			if i < 0 {
+				break
+			}
+			found++
+			if found == n {
+				return start + int64(i) + 1, n, nil
+			}
+			block = block[:i]
+		}
+		pos = start
+	}
+
+	return 0, found + 1, nil
+}
+
+// readTail reads all lines, but keeps only the last n lines in memory.
+// It returns them and the count of lines before them
+func readTail(reader *bufio.Reader, n int32) ([]byte, int32, error) {
+	lines := make([][]byte, 0, min(n, 1024))
+	oldest := 0 // the index of the oldest line once lines are full
+	var before int32
+	for {
+		l, err := reader.ReadBytes('\n')
+		if len(l) > 0 {
+			if int32(len(lines)) < n {
+				lines = append(lines, l)
+			} else {
+				lines[oldest] = l
+				oldest = (oldest + 1) % len(lines)
+				before++
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	tail := bytes.Join(append(lines[oldest:len(lines):len(lines)], lines[:oldest]...), nil)
+
+	return tail, before, nil
+}
+
+// resolveLineRanges converts negative line numbers to actual line numbers by totalLC
+func resolveLineRanges(ranges []lineRange, totalLC int32) []lineRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+
+	resolved := make([]lineRange, 0, len(ranges))
+	for _, lr := range ranges {
+		if lr.start < 0 {
+			lr.start = max(totalLC+lr.start+1, 1)
+		}
+		if lr.end < 0 {
+			lr.end = totalLC + lr.end + 1
+		}
+		resolved = append(resolved, lr)
+	}
+
+	return resolved
+}
+
+func isInLineRanges(ranges []lineRange, lc int32) bool {
+	for _, lr := range ranges {
+		if lr.start <= lc && lc <= lr.end {
+			return true
+		}
+	}
+
+	return false
+}
+
+func lastLineOfRanges(ranges []lineRange) int32 {
+	var last int32
+	for _, lr := range ranges {
+		last = max(last, lr.end)
+	}
+
+	return last
+}
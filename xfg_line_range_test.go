@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"math"
+	"strings"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestParseLineRanges(t *testing.T) {
+	t.Parallel()
+	ranges, err := parseLineRanges([]string{"100:200", "-50:", ":20,7"})
+	a.Got(err).NoError(t)
+	a.Got(ranges).Expect([]lineRange{
+		{start: 100, end: 200},
+		{start: -50, end: math.MaxInt32},
+		{start: 1, end: 20},
+		{start: 7, end: 7},
+	}).Same(t)
+	a.Got(hasNegativeLineRange(ranges)).True(t)
+
+	_, err = parseLineRanges([]string{"a:b"})
+	a.Got(err).Expect("wrong line range `a:b`").Match(t)
+
+	_, err = parseLineRanges([]string{"0:10"})
+	a.Got(err).Expect("should not be 0").Match(t)
+}
+
+func TestResolveLineRanges(t *testing.T) {
+	t.Parallel()
+	ranges := resolveLineRanges([]lineRange{{start: -3, end: math.MaxInt32}, {start: 1, end: -9}}, 10)
+	a.Got(ranges).Expect([]lineRange{{start: 8, end: math.MaxInt32}, {start: 1, end: 2}}).Same(t)
+	a.Got(isInLineRanges(ranges, 2)).True(t)
+	a.Got(isInLineRanges(ranges, 5)).False(t)
+	a.Got(isInLineRanges(ranges, 9)).True(t)
+	a.Got(lastLineOfRanges([]lineRange{{start: 1, end: 20}, {start: 5, end: 10}})).Expect(int32(20)).Same(t)
+}
+
+func TestTailLineCount(t *testing.T) {
+	t.Parallel()
+	a.Got(tailLineCount([]lineRange{{start: -3, end: math.MaxInt32}, {start: -9, end: -5}})).Expect(int32(9)).Same(t)
+	a.Got(tailLineCount([]lineRange{{start: -3, end: math.MaxInt32}, {start: 1, end: 20}})).Expect(int32(0)).Same(t)
+	a.Got(tailLineCount(nil)).Expect(int32(0)).Same(t)
+}
+
+func TestFindTail(t *testing.T) {
+	t.Parallel()
+	for tname, tt := range map[string]struct {
+		content      string
+		n            int32
+		expectOffset int64
+		expectLC     int32
+	}{
+		"last 2 lines":                 {content: "a\nb\nc\n", n: 2, expectOffset: 2, expectLC: 2},
+		"last 2 lines without last LF": {content: "a\nb\nc", n: 2, expectOffset: 2, expectLC: 2},
+		"all lines":                    {content: "a\nb\nc\n", n: 3, expectOffset: 0, expectLC: 3},
+		"short content":                {content: "a\nb\n", n: 5, expectOffset: 0, expectLC: 2},
+		"empty lines":                  {content: "\n\n\n", n: 1, expectOffset: 2, expectLC: 1},
+		"over blocks":                  {content: strings.Repeat("x", scanBlockBytes) + "\nlast\n", n: 1, expectOffset: scanBlockBytes + 1, expectLC: 1},
+		"empty":                        {content: "", n: 1, expectOffset: 0, expectLC: 0},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			offset, lc, err := findTail(strings.NewReader(tt.content), int64(len(tt.content)), tt.n)
+			a.Got(err).NoError(t)
+			a.Got(offset).Expect(tt.expectOffset).Same(t)
+			a.Got(lc).Expect(tt.expectLC).Same(t)
+		})
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	t.Parallel()
+	tail, before, err := readTail(bufio.NewReader(strings.NewReader("a\nb\nc\nd")), 2)
+	a.Got(err).NoError(t)
+	a.Got(string(tail)).Expect("c\nd").Same(t)
+	a.Got(before).Expect(int32(2)).Same(t)
+
+	tail, before, err = readTail(bufio.NewReader(strings.NewReader("a\nb\n")), 5)
+	a.Got(err).NoError(t)
+	a.Got(string(tail)).Expect("a\nb\n").Same(t)
+	a.Got(before).Expect(int32(0)).Same(t)
+}
@@ -0,0 +1,75 @@
+package main
+
+import "sync"
+
+// orderedStream is the reorder buffer for --ordered-stream and --deterministic.
+// Directories are walked in the order of sorted paths, so results are emitted in the same order as --keep-result-order.
+// Each walked path reserves a sequence number in the order of walking, then it is settled
+// with a result or nothing after scanning. Results are emitted as soon as all earlier paths are settled.
+// Walking must run in one goroutine to reserve sequence numbers in order.
+type orderedStream struct {
+	mu       sync.Mutex
+	reserved uint64
+	next     uint64 // the sequence number to emit next
+	pending  map[uint64]*path
+	emit     func(p path) error
+	err      error // the first error of emit
+}
+
+func newOrderedStream(emit func(p path) error) *orderedStream {
+	return &orderedStream{
+		pending: map[uint64]*path{},
+		emit:    emit,
+	}
+}
+
+func (ob *orderedStream) reserve() uint64 {
+	if ob == nil {
+		return 0
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	seq := ob.reserved
+	ob.reserved++
+
+	return seq
+}
+
+// settle settles the path of the sequence number with a result, or nil if it was not picked up.
+// Only the first settlement is used.
+func (ob *orderedStream) settle(seq uint64, p *path) {
+	if ob == nil {
+		return
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	if _, ok := ob.pending[seq]; ok || seq < ob.next {
+		return // already settled
+	}
+	ob.pending[seq] = p
+
+	for {
+		p, ok := ob.pending[ob.next]
+		if !ok {
+			break
+		}
+		delete(ob.pending, ob.next)
+		ob.next++
+		if p != nil && ob.err == nil {
+			ob.err = ob.emit(*p)
+		}
+	}
+}
+
+func (ob *orderedStream) error() error {
+	if ob == nil {
+		return nil
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	return ob.err
+}
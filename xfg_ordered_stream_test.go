@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestOrderedStream(t *testing.T) {
+	t.Parallel()
+	var got []string
+	ob := newOrderedStream(func(p path) error {
+		got = append(got, p.path)
+		return nil
+	})
+
+	for i := 0; i < 4; i++ {
+		a.Got(ob.reserve()).Expect(uint64(i)).Same(t)
+	}
+
+	ob.settle(2, &path{path: "c"})
+	ob.settle(1, nil)
+	a.Got(len(got)).Expect(0).Same(t) // waiting for 0
+
+	ob.settle(0, &path{path: "a"})
+	a.Got(got).Expect([]string{"a", "c"}).Same(t)
+
+	ob.settle(2, &path{path: "c again"}) // already settled
+	ob.settle(3, &path{path: "d"})
+	ob.settle(3, nil) // already settled
+	a.Got(got).Expect([]string{"a", "c", "d"}).Same(t)
+	a.Got(len(ob.pending)).Expect(0).Same(t)
+}
+
+func TestOrderedStream_Nil(t *testing.T) {
+	t.Parallel()
+	var ob *orderedStream
+	a.Got(ob.reserve()).Expect(uint64(0)).Same(t)
+	ob.settle(0, &path{path: "a"}) // no panic
+}
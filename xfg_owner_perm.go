@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/bayashi/xfg/internal/xfgutil"
+)
+
+const (
+	permSetuid uint32 = 04000
+	permSetgid uint32 = 02000
+	permSticky uint32 = 01000
+)
+
+type ownerCondition struct {
+	uid int64 // -1 means any user
+	gid int64 // -1 means any group
+}
+
+type permCondition struct {
+	op   byte // '/': any bits, '-': all bits, 0: bits in mask are exactly the same and other bits are set
+	mode uint32
+	mask uint32 // bits to be exactly the same as mode without op. All bits for an octal mode, bits of users for `=`
+}
+
+func (pc permCondition) match(mode uint32) bool {
+	switch pc.op {
+	case '/':
+		return pc.mode == 0 || mode&pc.mode != 0 // like find, `/0` matches any
+	case '-':
+		return mode&pc.mode == pc.mode
+	default:
+		set := pc.mode &^ pc.mask
+		return mode&pc.mask == pc.mode&pc.mask && mode&set == set
+	}
+}
+
+// parseOwner parses "user", "user:group", ":group" or numeric IDs
+func parseOwner(s string) (ownerCondition, error) {
+	oc := ownerCondition{uid: -1, gid: -1}
+	u, g, _ := strings.Cut(s, ":")
+	if u != "" {
+		uid, err := lookupID(u, func(name string) (string, error) {
+			usr, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return usr.Uid, nil
+		})
+		if err != nil {
+			return oc, fmt.Errorf("wrong owner `%s` : %w", s, err)
+		}
+		oc.uid = uid
+	}
+	if g != "" {
+		gid, err := lookupID(g, func(name string) (string, error) {
+			grp, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return grp.Gid, nil
+		})
+		if err != nil {
+			return oc, fmt.Errorf("wrong owner `%s` : %w", s, err)
+		}
+		oc.gid = gid
+	}
+
+	return oc, nil
+}
+
+func lookupID(nameOrID string, lookup func(string) (string, error)) (int64, error) {
+	if id, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		return id, nil
+	}
+
+	id, err := lookup(nameOrID)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// parsePerm parses an octal mode like "0644", or a symbolic mode like "o+w", "u=rw" or "u+s,g+s".
+// The prefix '/' means any of the bits are set, and '-' means all of the bits are set.
+// Without prefix, an octal mode is exactly the same, `+` means the bits are set, and `=` means the bits of the users are exactly the same.
+func parsePerm(s string) (permCondition, error) {
+	pc := permCondition{}
+	mode := s
+	if strings.HasPrefix(mode, "/") || strings.HasPrefix(mode, "-") {
+		pc.op = mode[0]
+		mode = mode[1:]
+	}
+
+	if m, err := strconv.ParseUint(mode, 8, 32); err == nil && m <= 07777 {
+		pc.mode = uint32(m)
+		if pc.op == 0 {
+			pc.mask = 07777
+		}
+		return pc, nil
+	}
+
+	for _, clause := range strings.Split(mode, ",") {
+		m, mask, err := parseSymbolicPerm(clause)
+		if err != nil {
+			return pc, fmt.Errorf("wrong perm `%s` : %w", s, err)
+		}
+		pc.mode |= m
+		if pc.op == 0 {
+			pc.mask |= mask
+		}
+	}
+
+	return pc, nil
+}
+
+// parseSymbolicPerm parses a clause like "o+w", "ug=rw" or "u+s".
+// exact is bits of the users for `=`, including the special bit of them, to be exactly the same
+func parseSymbolicPerm(clause string) (mode uint32, exact uint32, err error) {
+	i := strings.IndexAny(clause, "+=")
+	if i < 0 {
+		return 0, 0, fmt.Errorf("`%s` should have '+' or '='", clause)
+	}
+	who, perms := clause[:i], clause[i+1:]
+	if who == "" {
+		who = "a"
+	}
+
+	var mask uint32 // bits of users
+	for _, w := range who {
+		switch w {
+		case 'u':
+			mask |= 0700
+		case 'g':
+			mask |= 0070
+		case 'o':
+			mask |= 0007
+		case 'a':
+			mask |= 0777
+		default:
+			return 0, 0, fmt.Errorf("unknown user `%c`", w)
+		}
+	}
+	if clause[i] == '=' {
+		exact = mask
+		if mask&0700 != 0 {
+			exact |= permSetuid
+		}
+		if mask&0070 != 0 {
+			exact |= permSetgid
+		}
+		if mask&0007 != 0 {
+			exact |= permSticky
+		}
+	}
+
+	for _, p := range perms {
+		switch p {
+		case 'r':
+			mode |= 0444 & mask
+		case 'w':
+			mode |= 0222 & mask
+		case 'x':
+			mode |= 0111 & mask
+		case 's':
+			if mask&0700 != 0 {
+				mode |= permSetuid
+			}
+			if mask&0070 != 0 {
+				mode |= permSetgid
+			}
+		case 't':
+			mode |= permSticky
+		default:
+			return 0, 0, fmt.Errorf("unknown permission `%c`", p)
+		}
+	}
+
+	return mode, exact, nil
+}
+
+// unixMode converts fs.FileMode into the Unix style permission bits
+func unixMode(m fs.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	if m&fs.ModeSetuid != 0 {
+		mode |= permSetuid
+	}
+	if m&fs.ModeSetgid != 0 {
+		mode |= permSetgid
+	}
+	if m&fs.ModeSticky != 0 {
+		mode |= permSticky
+	}
+
+	return mode
+}
+
+func (x *xfg) prepareOwnerPermConditions() error {
+	if x.options.Owner != "" {
+		oc, err := parseOwner(x.options.Owner)
+		if err != nil {
+			return err
+		}
+		x.extra.ownerCondition = &oc
+	}
+
+	for _, perm := range x.options.Perm {
+		pc, err := parsePerm(perm)
+		if err != nil {
+			return err
+		}
+		x.extra.permConditions = append(x.extra.permConditions, pc)
+	}
+
+	if x.options.Setuid {
+		x.extra.permConditions = append(x.extra.permConditions, permCondition{op: '-', mode: permSetuid})
+	}
+
+	if x.options.Setgid {
+		x.extra.permConditions = append(x.extra.permConditions, permCondition{op: '-', mode: permSetgid})
+	}
+
+	return nil
+}
+
+func (x *xfg) hasOwnerPermCondition() bool {
+	return x.extra.ownerCondition != nil || len(x.extra.permConditions) > 0
+}
+
+func (x *xfg) isMatchOwnerPerm(fInfo fs.DirEntry) bool {
+	i, err := fInfo.Info()
+	if err != nil {
+		return false // trap error
+	}
+
+	if oc := x.extra.ownerCondition; oc != nil {
+		uid, gid, ok := xfgutil.Owner(i)
+		if !ok {
+			return false // not supported on this platform
+		}
+		if (oc.uid >= 0 && int64(uid) != oc.uid) || (oc.gid >= 0 && int64(gid) != oc.gid) {
+			return false
+		}
+	}
+
+	mode := unixMode(i.Mode())
+	for _, pc := range x.extra.permConditions {
+		if !pc.match(mode) {
+			return false
+		}
+	}
+
+	return true
+}
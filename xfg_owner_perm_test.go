@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestParsePerm(t *testing.T) {
+	t.Parallel()
+	for s, expect := range map[string]permCondition{
+		"0644":     {mode: 0644, mask: 07777},
+		"755":      {mode: 0755, mask: 07777},
+		"-0600":    {op: '-', mode: 0600},
+		"/o+w":     {op: '/', mode: 0002},
+		"/g+w,o+w": {op: '/', mode: 0022},
+		"ug=rw":    {mode: 0660, mask: 06770},
+		"+x":       {mode: 0111},
+		"/u+s":     {op: '/', mode: permSetuid},
+		"-a+t":     {op: '-', mode: permSticky},
+		"-u=rw":    {op: '-', mode: 0600},
+		"/0":       {op: '/'},
+	} {
+		pc, err := parsePerm(s)
+		a.Got(err).NoError(t)
+		a.Got(pc).Expect(expect).Debug("perm", s).Same(t)
+	}
+
+	_, err := parsePerm("o-w")
+	a.Got(err).Expect("wrong perm `o-w`").Match(t)
+
+	_, err = parsePerm("z+w")
+	a.Got(err).Expect("unknown user").Match(t)
+}
+
+func TestPermConditionMatch(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		perm   string
+		mode   uint32
+		expect bool
+	}{
+		{perm: "0644", mode: 0644, expect: true},
+		{perm: "0644", mode: 0664, expect: false},
+		{perm: "-0600", mode: 0644, expect: true},
+		{perm: "-0600", mode: 0400, expect: false},
+		{perm: "/0022", mode: 0602, expect: true},
+		{perm: "/0022", mode: 0644, expect: false},
+		{perm: "/0", mode: 0, expect: true},
+		{perm: "/0", mode: 0644, expect: true},
+		// `+` means the bits are set
+		{perm: "u+rw", mode: 0600, expect: true},
+		{perm: "u+rw", mode: 0744, expect: true},
+		{perm: "u+rw", mode: 0400, expect: false},
+		// `=` means the bits of the users are exactly the same
+		{perm: "u=rw", mode: 0600, expect: true},
+		{perm: "u=rw", mode: 0644, expect: true},
+		{perm: "u=rw", mode: 0700, expect: false},
+		{perm: "u=rw", mode: 04600, expect: false},
+		{perm: "o=", mode: 0640, expect: true},
+		{perm: "o=", mode: 0644, expect: false},
+		{perm: "u=rw,o+r", mode: 0604, expect: true},
+		{perm: "u=rw,o+r", mode: 0600, expect: false},
+		{perm: "/u=rw", mode: 0200, expect: true},
+		{perm: "-u=rw", mode: 0700, expect: true},
+		{perm: "-u=rw", mode: 0400, expect: false},
+	} {
+		pc, err := parsePerm(tt.perm)
+		a.Got(err).NoError(t)
+		a.Got(pc.match(tt.mode)).Expect(tt.expect).X().Debug("perm", tt.perm).Debug("mode", fmt.Sprintf("%04o", tt.mode)).Same(t)
+	}
+}
+
+func TestUnixMode(t *testing.T) {
+	t.Parallel()
+	a.Got(unixMode(0755)).Expect(uint32(0755)).Same(t)
+	a.Got(unixMode(0755 | fs.ModeSetuid | fs.ModeSticky)).Expect(uint32(05755)).Same(t)
+}
+
+func TestParseOwner(t *testing.T) {
+	t.Parallel()
+	oc, err := parseOwner("1000:100")
+	a.Got(err).NoError(t)
+	a.Got(oc).Expect(ownerCondition{uid: 1000, gid: 100}).Same(t)
+
+	oc, err = parseOwner(":100")
+	a.Got(err).NoError(t)
+	a.Got(oc).Expect(ownerCondition{uid: -1, gid: 100}).Same(t)
+
+	_, err = parseOwner(noMatchKeyword)
+	a.Got(err).Expect("wrong owner").Match(t)
+}
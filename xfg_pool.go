@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/bayashi/xfg/internal/xfgstats"
+	"github.com/bayashi/xfg/internal/xfgutil"
+)
+
+// workerPool runs walking directories and scanning files with bounded goroutines.
+// Walking tasks run in the caller goroutine when all walk workers are busy, because
+// walking tasks submit other walking tasks and must not wait each other.
+// Scanning tasks wait for a free scan worker, then walking slows down instead of opening too many files.
+// All tasks run in the caller goroutine in order if threads is 1.
+type workerPool struct {
+	threads        int
+	sequentialWalk bool // walk in the caller goroutine in order, but scan in parallel
+	walk           *errgroup.Group
+	scan           *errgroup.Group
+
+	errOnce sync.Once
+	err     error // the first error of tasks which ran in the caller goroutine
+
+	walkCount  poolCount
+	scanCount  poolCount
+	walkInline atomic.Int64
+	scanWait   atomic.Int64 // nanoseconds to wait for a free scan worker
+}
+
+type poolCount struct {
+	tasks  atomic.Int64
+	active atomic.Int64
+	peak   atomic.Int64
+}
+
+func newWorkerPool(threads int) *workerPool {
+	if threads <= 0 {
+		threads = xfgutil.Procs()
+	}
+
+	wp := &workerPool{
+		threads: threads,
+		walk:    new(errgroup.Group),
+		scan:    new(errgroup.Group),
+	}
+	wp.walk.SetLimit(threads)
+	wp.scan.SetLimit(threads)
+
+	return wp
+}
+
+func (wp *workerPool) isSequential() bool {
+	return wp.threads == 1
+}
+
+// walksInOrder returns true if directories are walked one by one in the caller goroutine
+func (wp *workerPool) walksInOrder() bool {
+	return wp.isSequential() || wp.sequentialWalk
+}
+
+func (wp *workerPool) goWalk(f func() error) {
+	task := wp.walkCount.wrap(f)
+	if !wp.walksInOrder() && wp.walk.TryGo(task) {
+		return
+	}
+
+	wp.walkInline.Add(1)
+	wp.setErr(task())
+}
+
+func (wp *workerPool) goScan(f func() error) {
+	task := wp.scanCount.wrap(f)
+	if wp.isSequential() {
+		wp.setErr(task())
+		return
+	}
+
+	if wp.scan.TryGo(task) {
+		return
+	}
+
+	start := time.Now()
+	wp.scan.Go(task)
+	wp.scanWait.Add(int64(time.Since(start)))
+}
+
+func (wp *workerPool) setErr(err error) {
+	if err != nil {
+		wp.errOnce.Do(func() { wp.err = err })
+	}
+}
+
+// wait waits walking tasks at first, because they submit scanning tasks
+func (wp *workerPool) wait() error {
+	walkErr := wp.walk.Wait()
+	scanErr := wp.scan.Wait()
+
+	if walkErr != nil {
+		return walkErr
+	} else if scanErr != nil {
+		return scanErr
+	}
+
+	return wp.err
+}
+
+func (wp *workerPool) stats() (walk xfgstats.Pool, scan xfgstats.Pool) {
+	walk = xfgstats.Pool{
+		Workers: wp.threads,
+		Tasks:   int(wp.walkCount.tasks.Load()),
+		Peak:    int(wp.walkCount.peak.Load()),
+		Inline:  int(wp.walkInline.Load()),
+	}
+	scan = xfgstats.Pool{
+		Workers: wp.threads,
+		Tasks:   int(wp.scanCount.tasks.Load()),
+		Peak:    int(wp.scanCount.peak.Load()),
+		Wait:    time.Duration(wp.scanWait.Load()),
+	}
+
+	return walk, scan
+}
+
+func (pc *poolCount) wrap(f func() error) func() error {
+	return func() error {
+		pc.tasks.Add(1)
+		active := pc.active.Add(1)
+		for {
+			peak := pc.peak.Load()
+			if active <= peak || pc.peak.CompareAndSwap(peak, active) {
+				break
+			}
+		}
+		defer pc.active.Add(-1)
+
+		return f()
+	}
+}
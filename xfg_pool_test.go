@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestWorkerPool_Bounded(t *testing.T) {
+	t.Parallel()
+	wp := newWorkerPool(2)
+
+	var active, peak atomic.Int64
+	task := func() error {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		return nil
+	}
+	for i := 0; i < 10; i++ {
+		wp.goWalk(func() error {
+			for j := 0; j < 5; j++ {
+				wp.goScan(task)
+			}
+			return nil
+		})
+	}
+
+	a.Got(wp.wait()).NoError(t)
+	a.Got(peak.Load() <= 2).True(t)
+
+	walk, scan := wp.stats()
+	a.Got(walk.Workers).Expect(2).Same(t)
+	a.Got(walk.Tasks).Expect(10).Same(t)
+	a.Got(walk.Peak <= 3).True(t) // + the caller goroutine
+	a.Got(scan.Tasks).Expect(50).Same(t)
+	a.Got(scan.Peak <= 2).True(t)
+}
+
+func TestWorkerPool_Sequential(t *testing.T) {
+	t.Parallel()
+	wp := newWorkerPool(1)
+
+	var mu sync.Mutex
+	var got []int
+	for i := 0; i < 3; i++ {
+		wp.goWalk(func() error {
+			for j := 0; j < 3; j++ {
+				wp.goScan(func() error {
+					mu.Lock()
+					got = append(got, i*10+j)
+					mu.Unlock()
+					return nil
+				})
+			}
+			return nil
+		})
+	}
+
+	a.Got(wp.wait()).NoError(t)
+	a.Got(got).Expect([]int{0, 1, 2, 10, 11, 12, 20, 21, 22}).Same(t)
+
+	walk, _ := wp.stats()
+	a.Got(walk.Inline).Expect(3).Same(t)
+}
+
+func TestWorkerPool_Err(t *testing.T) {
+	t.Parallel()
+	errFoo := errors.New("foo")
+	for _, threads := range []int{1, 2} {
+		wp := newWorkerPool(threads)
+		wp.goWalk(func() error {
+			wp.goScan(func() error { return errFoo })
+			return nil
+		})
+		a.Got(errors.Is(wp.wait(), errFoo)).True(t)
+	}
+}
+
+func TestWorkerPool_DefaultThreads(t *testing.T) {
+	t.Parallel()
+	wp := newWorkerPool(0)
+	a.Got(wp.threads > 0).True(t)
+}
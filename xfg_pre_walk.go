@@ -6,6 +6,8 @@ import (
 )
 
 func (x *xfg) preWalkDir() error {
+	x.extra.dirIgnoreFiles = x.dirIgnoreFiles()
+
 	if x.options.IgnoreCase {
 		if err := x.prepareIgnoreCaseRe(); err != nil {
 			return err
@@ -31,6 +33,42 @@ func (x *xfg) preWalkDir() error {
 		}
 	}
 
+	x.extra.contentMatcher = newContentMatcher(x.options, x.extra.searchGrepi, x.extra.searchGrepRe)
+
+	if len(x.options.Lines) > 0 {
+		if lineRanges, err := parseLineRanges(x.options.Lines); err != nil {
+			return err
+		} else {
+			x.extra.lineRanges = lineRanges
+			x.extra.tailLC = tailLineCount(lineRanges)
+			x.extra.needTotalLC = x.extra.tailLC == 0 && hasNegativeLineRange(lineRanges)
+		}
+	}
+
+	if len(x.options.Size) > 0 {
+		if sizeConditions, err := parseSizeConditions(x.options.Size); err != nil {
+			return err
+		} else {
+			x.extra.sizeConditions = sizeConditions
+		}
+	}
+
+	if err := x.prepareTimeConditions(); err != nil {
+		return err
+	}
+
+	if err := x.prepareOwnerPermConditions(); err != nil {
+		return err
+	}
+
+	if x.options.MaxFilesize != "" {
+		if maxFilesize, err := parseSize(x.options.MaxFilesize); err != nil {
+			return err
+		} else {
+			x.extra.maxFilesize = maxFilesize
+		}
+	}
+
 	return nil
 }
 
@@ -64,19 +102,46 @@ func (x *xfg) prepareIgnoreOption() error {
 	return nil
 }
 
-func (x *xfg) initIgnoreMatchers(rootDir string) xfgignore.Matchers {
-	var gms xfgignore.Matchers
+func (x *xfg) initIgnoreMatchers(rootDir string) *xfgignore.Matcher {
+	var sets []*xfgignore.RuleSet
 	if !x.options.SkipGitIgnore {
-		if ms := xfgignore.SetUpGlobalGitIgnores(rootDir, x.cli.homeDir); len(ms) > 0 {
-			gms = append(gms, ms...)
-		}
+		sets = append(sets, xfgignore.SetUpGlobalGitIgnores(rootDir, x.cli.homeDir)...)
 	}
 
 	if !x.options.SkipXfgIgnore {
-		if ms := xfgignore.SetupGlobalXFGIgnore(rootDir, x.cli.homeDir, x.options.XfgIgnoreFile); len(ms) > 0 {
-			gms = append(gms, ms...)
+		sets = append(sets, xfgignore.SetupGlobalXFGIgnore(rootDir, x.cli.homeDir, x.options.XfgIgnoreFile)...)
+	}
+
+	for range sets {
+		x.countIgnoreFile()
+	}
+
+	return xfgignore.NewMatcher(sets...)
+}
+
+// dirIgnoreFiles returns names of ignore files which are read in each directory, in order of precedence from the lowest
+func (x *xfg) dirIgnoreFiles() []string {
+	var names []string
+	for _, f := range []struct {
+		name string
+		skip bool
+	}{
+		{name: xfgignore.GITIGNORE_FILE_NAME, skip: x.options.SkipGitIgnore},
+		{name: xfgignore.DOT_IGNORE_FILE_NAME, skip: x.options.SkipDotIgnore},
+		{name: xfgignore.RGIGNORE_FILE_NAME, skip: x.options.SkipRgIgnore},
+		{name: xfgignore.FDIGNORE_FILE_NAME, skip: x.options.SkipFdIgnore},
+		{name: xfgignore.XFGIGNORE_FILE_NAME, skip: x.options.SkipXfgIgnore},
+	} {
+		if !f.skip {
+			names = append(names, f.name)
 		}
 	}
 
-	return gms
+	return names
+}
+
+func (x *xfg) countIgnoreFile() {
+	if x.options.Stats {
+		x.cli.stats.IncrIgnoreFile()
+	}
 }
@@ -1,21 +1,22 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
-
-	"golang.org/x/sync/errgroup"
+	"time"
 
 	"github.com/bayashi/xfg/internal/xfgignore"
 	"github.com/bayashi/xfg/internal/xfglangxt"
-	"github.com/monochromegane/go-gitignore"
+	"github.com/bayashi/xfg/internal/xfgutil"
 )
 
-func (x *xfg) process() error {
+func (x *xfg) process(ctx context.Context) error {
 	if err := x.preWalkDir(); err != nil {
 		return fmt.Errorf("preWalkDir() : %w", err)
 	}
@@ -31,20 +32,50 @@ func (x *xfg) process() error {
 		go x.streamDisplay()
 	}
 
-	eg := new(errgroup.Group)
-	for _, startDir := range x.options.SearchStart {
+	// --deterministic keeps the order of walking even if results are sorted later, to pick the same results by --max-results
+	if (x.options.OrderedStream || x.options.Deterministic) && !x.options.Quiet {
+		x.ordered = newOrderedStream(x.acceptResult)
+	}
+
+	wp := newWorkerPool(int(x.options.Threads))
+	wp.sequentialWalk = x.ordered != nil
+	if x.options.FilesFrom != "" {
+		if err := x.walkFilesFrom(ctx, wp); err != nil {
+			return fmt.Errorf("walkFilesFrom() : %w", err)
+		}
+	}
+	for _, startDir := range x.searchStartDirs() {
 		startDir := startDir
-		eg.Go(func() error {
-			ms := x.initIgnoreMatchers(startDir)
-			x.walkDir(eg, startDir, ms, uint32(1))
-			return nil
-		})
+		if startDir == stdinStartPath {
+			seq := x.ordered.reserve()
+			wp.goScan(func() error {
+				defer x.ordered.settle(seq, nil)
+				return x.scanStdin(ctx, seq)
+			})
+			continue
+		}
+		if fi, err := os.Stat(startDir); err == nil && !fi.IsDir() {
+			if err := x.walkStartFile(ctx, wp, startDir); err != nil {
+				return fmt.Errorf("walkStartFile() : %w", err)
+			}
+			continue
+		}
+		im := x.initIgnoreMatchers(startDir)
+		x.walkDir(ctx, wp, startDir, im, uint32(1), x.startAncestors(startDir))
 	}
 
-	if err := eg.Wait(); err != nil {
+	if err := wp.wait(); err != nil {
 		return fmt.Errorf("walkDir Wait : %w", err)
 	}
 
+	if err := x.ordered.error(); err != nil {
+		return fmt.Errorf("ordered results : %w", err)
+	}
+
+	if x.options.Stats {
+		x.cli.stats.SetPool(wp.stats())
+	}
+
 	// Close channel and wait for streaming display to finish
 	if !x.options.KeepResultOrder && !x.options.Quiet {
 		close(x.resultChan)
@@ -54,20 +85,16 @@ func (x *xfg) process() error {
 	return nil
 }
 
-func (x *xfg) walkDir(eg *errgroup.Group, dirPath string, ms xfgignore.Matchers, currentDepth uint32) {
-	eg.Go(func() error {
+// ancestors are the directories from the start directory to dirPath. Only used to follow symbolic links
+func (x *xfg) walkDir(ctx context.Context, wp *workerPool, dirPath string, im *xfgignore.Matcher, currentDepth uint32, ancestors []fs.FileInfo) {
+	wp.goWalk(func() error {
 		if currentDepth > x.options.MaxDepth {
 			return nil
 		} else {
 			currentDepth++
 		}
-		if !x.options.SkipGitIgnore {
-			if matcher, err := gitignore.NewGitIgnore(filepath.Join(dirPath, xfgignore.GITIGNORE_FILE_NAME)); err == nil {
-				ms = append(ms, matcher)
-			}
-		}
-		if x.options.Quiet && x.hasMatchedAny() {
-			return nil // already match. skip after all
+		if isDone(ctx) {
+			return nil // stopped. skip after all
 		}
 		stuff, err := os.ReadDir(dirPath)
 		if err != nil {
@@ -80,16 +107,56 @@ func (x *xfg) walkDir(eg *errgroup.Group, dirPath string, ms xfgignore.Matchers,
 			return err
 		}
 
-		x.walkStuff(stuff, eg, dirPath, ms, currentDepth)
+		im = x.withDirIgnoreFiles(im, dirPath, func(name string) bool {
+			// os.ReadDir returns entries sorted by name
+			i := sort.Search(len(stuff), func(i int) bool { return stuff[i].Name() >= name })
+			return i < len(stuff) && stuff[i].Name() == name && !stuff[i].IsDir()
+		})
+
+		x.walkStuff(ctx, stuff, wp, dirPath, im, currentDepth, ancestors)
 
 		return nil
 	})
 }
 
-func (x *xfg) walkStuff(stuff []fs.DirEntry, eg *errgroup.Group, dirPath string, ms xfgignore.Matchers, currentDepth uint32) {
+// withDirIgnoreFiles returns a matcher with rules of ignore files in the directory. has tells whether the directory has the file or not
+func (x *xfg) withDirIgnoreFiles(im *xfgignore.Matcher, dirPath string, has func(name string) bool) *xfgignore.Matcher {
+	for _, name := range x.extra.dirIgnoreFiles {
+		if !has(name) {
+			continue
+		}
+		if set, err := xfgignore.LoadRuleSet(filepath.Join(dirPath, name), dirPath); err == nil {
+			x.countIgnoreFile()
+			im = im.With(set)
+		}
+	}
+
+	return im
+}
+
+func (x *xfg) walkStuff(ctx context.Context, stuff []fs.DirEntry, wp *workerPool, dirPath string, im *xfgignore.Matcher, currentDepth uint32, ancestors []fs.FileInfo) {
+	var dirDev uint64
+	var hasDirDev bool
+	if x.options.OneFileSystem {
+		if fi, err := os.Stat(dirPath); err == nil {
+			dirDev, hasDirDev = xfgutil.DeviceID(fi)
+		}
+	}
+
+	if x.options.Follow {
+		for i, s := range stuff {
+			if (s.Type() & fs.ModeSymlink) == fs.ModeSymlink {
+				stuff[i] = x.followSymlink(filepath.Join(dirPath, s.Name()), s)
+			}
+		}
+	}
+	if wp.walksInOrder() {
+		sortByResultPath(stuff)
+	}
+
 	for _, s := range stuff {
-		if x.options.Quiet && x.hasMatchedAny() {
-			break // already match. skip after all
+		if isDone(ctx) {
+			break // stopped. skip after all
 		}
 		if !x.options.SearchAll && !x.options.SearchDefaultSkipStuff {
 			if (!x.options.NoDefaultSkip && isDefaultSkipDir(s)) ||
@@ -97,23 +164,72 @@ func (x *xfg) walkStuff(stuff []fs.DirEntry, eg *errgroup.Group, dirPath string,
 				continue // skip all stuff in this dir
 			}
 		}
+		p := filepath.Join(dirPath, s.Name())
+		descend := false
+		var next []fs.FileInfo
 		if s.IsDir() {
-			p := filepath.Join(dirPath, s.Name())
-			if !x.options.SearchAll && x.isSkippableByIgnoreFile(p, ms) {
+			if !x.options.SearchAll && x.isSkippableByIgnoreFile(p, true, im) {
 				continue // skip all stuff in this dir
 			}
-			x.walkDir(eg, p, ms, currentDepth) // recursively
+			if x.options.Follow {
+				fi, err := s.Info()
+				if err != nil {
+					x.cli.putErr(err)
+					continue
+				}
+				if isFileSystemLoop(fi, ancestors) {
+					x.cli.putErr(fmt.Sprintf("file system loop found: `%s`", p))
+					continue
+				}
+				next = append(ancestors[:len(ancestors):len(ancestors)], fi)
+			}
+			descend = !hasDirDev || isSameDevice(s, dirDev)
+		}
+		x.walkFile(ctx, wp, p, s, im, currentDepth-1, false) // the directory itself before its contents
+		if descend {
+			x.walkDir(ctx, wp, p, im, currentDepth, next) // recursively
 		}
-		x.walkFile(eg, filepath.Join(dirPath, s.Name()), s, ms)
 	}
 }
 
-func (x *xfg) walkFile(eg *errgroup.Group, fPath string, fInfo fs.DirEntry, ms xfgignore.Matchers) error {
+// sortByResultPath sorts entries in the same order as paths of sorted results.
+// A directory is shown with a path separator, e.g. `a.txt` is sorted before `a/` and `a/b.txt`
+func sortByResultPath(stuff []fs.DirEntry) {
+	key := func(s fs.DirEntry) string {
+		if s.IsDir() {
+			return s.Name() + string(filepath.Separator)
+		}
+		return s.Name()
+	}
+	sort.SliceStable(stuff, func(i, j int) bool { return key(stuff[i]) < key(stuff[j]) })
+}
+
+// isSameDevice returns true if the device of the directory is not known
+func isSameDevice(dir fs.DirEntry, dev uint64) bool {
+	fi, err := dir.Info()
+	if err != nil {
+		return true
+	}
+	d, ok := xfgutil.DeviceID(fi)
+
+	return !ok || d == dev
+}
+
+// explicit is true for a file which is given as a start path. It is searched even if it is hidden, skipped by default or ignored
+func (x *xfg) walkFile(ctx context.Context, wp *workerPool, fPath string, fInfo fs.DirEntry, im *xfgignore.Matcher, depth uint32, explicit bool) error {
 	if x.options.Stats {
 		x.cli.stats.IncrWalkedPaths()
 	}
 
-	if x.isSkippablePath(fPath, fInfo, ms) {
+	seq := x.ordered.reserve()
+
+	if !explicit && depth < x.options.MinDepth {
+		x.ordered.settle(seq, nil)
+		return nil // still walked, but not pick up
+	}
+
+	if x.isSkippablePath(fPath, fInfo, im, explicit) {
+		x.ordered.settle(seq, nil)
 		return nil
 	}
 
@@ -121,8 +237,9 @@ func (x *xfg) walkFile(eg *errgroup.Group, fPath string, fInfo fs.DirEntry, ms x
 		x.cli.stats.IncrWalkedContents()
 	}
 
-	eg.Go(func() error {
-		return x.postMatchPath(fPath, fInfo)
+	wp.goScan(func() error {
+		defer x.ordered.settle(seq, nil) // not picked up if not settled yet
+		return x.postMatchPath(ctx, fPath, fInfo, depth, seq)
 	})
 
 	return nil
@@ -151,8 +268,29 @@ func (x *xfg) isLangFile(fInfo fs.DirEntry) bool {
 	return false
 }
 
-func (x *xfg) isMatchFileType(fPath string, fInfo fs.DirEntry) bool {
-	switch x.options.Type {
+// isMatchFileTypes matches any of positive types, and none of negated types
+func (x *xfg) isMatchFileTypes(fPath string, fInfo fs.DirEntry) bool {
+	hasPositive, matchPositive := false, false
+	for _, t := range x.options.Type {
+		if negated, ok := strings.CutPrefix(t, "!"); ok {
+			if x.isMatchFileType(fPath, fInfo, negated) {
+				return false
+			}
+		} else {
+			hasPositive = true
+			if !matchPositive && x.isMatchFileType(fPath, fInfo, t) {
+				matchPositive = true
+			}
+		}
+	}
+
+	return !hasPositive || matchPositive
+}
+
+func (x *xfg) isMatchFileType(fPath string, fInfo fs.DirEntry, fileType string) bool {
+	switch fileType {
+	case "f", "file":
+		return fInfo.Type().IsRegular()
 	case "d", "directory":
 		return fInfo.IsDir()
 	case "l", "symlink":
@@ -193,15 +331,30 @@ func (x *xfg) isMatchFileType(fPath string, fInfo fs.DirEntry) bool {
 	}
 }
 
-func (x *xfg) isSkippablePath(fPath string, fInfo fs.DirEntry, ms xfgignore.Matchers) bool {
+func (x *xfg) isSkippablePath(fPath string, fInfo fs.DirEntry, im *xfgignore.Matcher, explicit bool) bool {
 	if !x.options.SearchAll {
 		if (len(x.options.Ext) > 0 && !x.isMatchExt(fInfo, x.options.Ext)) ||
 			(len(x.options.Lang) > 0 && !x.isLangFile(fInfo)) ||
-			(x.options.Type != "" && !x.isMatchFileType(fPath, fInfo)) {
+			(len(x.options.Type) > 0 && !x.isMatchFileTypes(fPath, fInfo)) {
 			return true
 		}
 	}
 
+	if len(x.extra.sizeConditions) > 0 && !x.isMatchSize(fInfo) {
+		if x.options.Stats && !fInfo.IsDir() {
+			x.cli.stats.IncrSkippedBySize()
+		}
+		return true
+	}
+
+	if x.hasTimeCondition() && !x.isMatchModTime(fInfo) {
+		return true
+	}
+
+	if x.hasOwnerPermCondition() && !x.isMatchOwnerPerm(fInfo) {
+		return true
+	}
+
 	if fInfo.IsDir() && x.options.extra.onlyMatchContent {
 		return true // Just not pick up only this dir path. It will be searched files and directories in this dir.
 	}
@@ -210,10 +363,10 @@ func (x *xfg) isSkippablePath(fPath string, fInfo fs.DirEntry, ms xfgignore.Matc
 		return true
 	}
 
-	if !x.options.SearchAll && !x.options.SearchDefaultSkipStuff {
+	if !explicit && !x.options.SearchAll && !x.options.SearchDefaultSkipStuff {
 		if (!x.options.NoDefaultSkip && isDefaultSkipFile(fInfo)) ||
 			(!x.options.Hidden && strings.HasPrefix(fInfo.Name(), ".")) ||
-			x.isSkippableByIgnoreFile(fPath, ms) {
+			x.isSkippableByIgnoreFile(fPath, fInfo.IsDir(), im) {
 			return true
 		}
 	}
@@ -221,16 +374,17 @@ func (x *xfg) isSkippablePath(fPath string, fInfo fs.DirEntry, ms xfgignore.Matc
 	return x.canSkipPath(fPath, fInfo)
 }
 
-func (x *xfg) isSkippableByIgnoreFile(fPath string, ms xfgignore.Matchers) bool {
-	if len(ms) > 0 {
-		for _, im := range ms {
-			if im.Match(fPath, false) {
-				return true
-			}
-		}
+func (x *xfg) isSkippableByIgnoreFile(fPath string, isDir bool, im *xfgignore.Matcher) bool {
+	if im == nil {
+		return false
 	}
 
-	return false
+	if x.options.Stats {
+		start := time.Now()
+		defer func() { x.cli.stats.AddIgnoreEval(time.Since(start)) }()
+	}
+
+	return im.Match(fPath, isDir)
 }
 
 func (x *xfg) isIgnorePath(fPath string) bool {
@@ -288,8 +442,8 @@ func (x *xfg) _canSkipPath(fPath string) bool {
 func (x *xfg) hasMatchedAny() bool {
 	x.result.mu.RLock()
 	defer x.result.mu.RUnlock()
-	if (len(x.options.SearchGrep) == 0 && len(x.result.paths) > 0) ||
-		(len(x.options.SearchGrep) > 0 && len(x.result.paths) > 0 && x.result.alreadyMatchContent) {
+	if (len(x.options.SearchGrep) == 0 && x.result.pickedCount > 0) ||
+		(len(x.options.SearchGrep) > 0 && x.result.pickedCount > 0 && x.result.alreadyMatchContent) {
 		return true // already match
 	}
 
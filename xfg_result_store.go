@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+)
+
+// resultStore keeps results to show them in order after searching.
+// Results are spilled into temporary files as sorted runs past the threshold of memory,
+// then these runs are merged on output.
+type resultStore struct {
+	threshold int // rough bytes of results in memory to spill. 0 means never spill
+	less      func(a path, b path) bool
+	size      int // rough bytes of results in memory
+	paths     []path
+	runs      []string // temporary files of sorted results
+	count     int
+}
+
+func newResultStore(threshold int) *resultStore {
+	return &resultStore{
+		threshold: threshold,
+		less:      func(a path, b path) bool { return a.path < b.path },
+	}
+}
+
+// spilledPath is the encoded path in a temporary file
+type spilledPath struct {
+	Path     string
+	Name     string
+	Mode     fs.FileMode
+	Size     int64
+	ModTime  time.Time
+	Depth    uint32
+	Contents []spilledLine
+}
+
+type spilledLine struct {
+	LC      int32
+	Content string
+	Matched bool
+}
+
+// spilledEntry is a pseudo fs.DirEntry of a path which was read from a temporary file
+type spilledEntry struct {
+	sp *spilledPath
+}
+
+func (e spilledEntry) Name() string               { return e.sp.Name }
+func (e spilledEntry) IsDir() bool                { return e.sp.Mode.IsDir() }
+func (e spilledEntry) Type() fs.FileMode          { return e.sp.Mode.Type() }
+func (e spilledEntry) Info() (fs.FileInfo, error) { return spilledInfo(e), nil }
+
+type spilledInfo struct {
+	sp *spilledPath
+}
+
+func (i spilledInfo) Name() string       { return i.sp.Name }
+func (i spilledInfo) Size() int64        { return i.sp.Size }
+func (i spilledInfo) Mode() fs.FileMode  { return i.sp.Mode }
+func (i spilledInfo) ModTime() time.Time { return i.sp.ModTime }
+func (i spilledInfo) IsDir() bool        { return i.sp.Mode.IsDir() }
+func (i spilledInfo) Sys() any           { return nil }
+
+func (rs *resultStore) add(p path) error {
+	rs.paths = append(rs.paths, p)
+	rs.count++
+	rs.size = rs.size + roughSize(p)
+
+	if rs.threshold > 0 && rs.size >= rs.threshold {
+		return rs.spill()
+	}
+
+	return nil
+}
+
+func (rs *resultStore) len() int {
+	return rs.count
+}
+
+func roughSize(p path) int {
+	size := len(p.path) + 64
+	for _, l := range p.contents {
+		size = size + len(l.content) + 16
+	}
+
+	return size
+}
+
+func (rs *resultStore) spill() error {
+	sort.SliceStable(rs.paths, func(i, j int) bool { return rs.less(rs.paths[i], rs.paths[j]) })
+
+	fh, err := os.CreateTemp("", "xfg-result-*")
+	if err != nil {
+		return fmt.Errorf("could not create a temporary file to spill results : %w", err)
+	}
+	defer fh.Close()
+	rs.runs = append(rs.runs, fh.Name())
+
+	enc := gob.NewEncoder(fh)
+	for _, p := range rs.paths {
+		if err := enc.Encode(toSpilledPath(p)); err != nil {
+			return fmt.Errorf("could not spill results into `%s` : %w", fh.Name(), err)
+		}
+	}
+
+	rs.paths = nil
+	rs.size = 0
+
+	return nil
+}
+
+func toSpilledPath(p path) *spilledPath {
+	sp := &spilledPath{
+		Path:  p.path,
+		Name:  p.info.Name(),
+		Mode:  p.info.Type(),
+		Depth: p.depth,
+	}
+	if fi, err := p.info.Info(); err == nil {
+		sp.Mode = fi.Mode()
+		sp.Size = fi.Size()
+		sp.ModTime = fi.ModTime()
+	}
+	for _, l := range p.contents {
+		sp.Contents = append(sp.Contents, spilledLine{LC: l.lc, Content: l.content, Matched: l.matched})
+	}
+
+	return sp
+}
+
+func fromSpilledPath(sp *spilledPath) path {
+	p := path{
+		path:  sp.Path,
+		info:  spilledEntry{sp: sp},
+		depth: sp.Depth,
+	}
+	for _, l := range sp.Contents {
+		p.contents = append(p.contents, line{lc: l.LC, content: l.Content, matched: l.Matched})
+	}
+
+	return p
+}
+
+// each calls fn for each result in order, merging spilled runs and results in memory
+func (rs *resultStore) each(fn func(i int, p path) error) error {
+	sort.SliceStable(rs.paths, func(i, j int) bool { return rs.less(rs.paths[i], rs.paths[j]) })
+
+	if len(rs.runs) == 0 {
+		for i, p := range rs.paths {
+			if err := fn(i, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	type run struct {
+		dec  *gob.Decoder
+		head *path
+	}
+	var runs []*run
+	for _, name := range rs.runs {
+		fh, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("could not open spilled results `%s` : %w", name, err)
+		}
+		defer fh.Close()
+		runs = append(runs, &run{dec: gob.NewDecoder(fh)})
+	}
+
+	next := func(r *run) error {
+		var sp spilledPath
+		if err := r.dec.Decode(&sp); err != nil {
+			r.head = nil
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("could not read spilled results : %w", err)
+		}
+		p := fromSpilledPath(&sp)
+		r.head = &p
+		return nil
+	}
+	for _, r := range runs {
+		if err := next(r); err != nil {
+			return err
+		}
+	}
+
+	mem := 0
+	for i := 0; ; i++ {
+		var first *run
+		for _, r := range runs {
+			if r.head != nil && (first == nil || rs.less(*r.head, *first.head)) {
+				first = r
+			}
+		}
+		if mem < len(rs.paths) && (first == nil || rs.less(rs.paths[mem], *first.head)) {
+			if err := fn(i, rs.paths[mem]); err != nil {
+				return err
+			}
+			mem++
+			continue
+		}
+		if first == nil {
+			break // all done
+		}
+		if err := fn(i, *first.head); err != nil {
+			return err
+		}
+		if err := next(first); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// close removes temporary files
+func (rs *resultStore) close() {
+	for _, name := range rs.runs {
+		os.Remove(name)
+	}
+	rs.runs = nil
+}
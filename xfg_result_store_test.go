@@ -0,0 +1,73 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestResultStore(t *testing.T) {
+	t.Parallel()
+	dirInfo, err := os.Lstat("testdata")
+	a.Got(err).NoError(t)
+	fileInfo, err := os.Lstat("testdata/service-b/main.go")
+	a.Got(err).NoError(t)
+	dir := fs.FileInfoToDirEntry(dirInfo)
+	file := fs.FileInfoToDirEntry(fileInfo)
+
+	for tname, threshold := range map[string]int{
+		"in memory":     0,
+		"spill each":    1,
+		"spill halfway": 200,
+	} {
+		t.Run(tname, func(t *testing.T) {
+			t.Parallel()
+			rs := newResultStore(threshold)
+			defer rs.close()
+
+			for _, p := range []path{
+				{path: "c", info: file, contents: []line{{lc: 3, content: "foo", matched: true}}},
+				{path: "a/", info: dir, depth: 2},
+				{path: "d", info: file},
+				{path: "b", info: file, contents: []line{{lc: 1, content: "bar"}, {lc: 2, content: "baz", matched: true}}},
+			} {
+				a.Got(rs.add(p)).NoError(t)
+			}
+			a.Got(rs.len()).Expect(4).Same(t)
+
+			var got []string
+			err := rs.each(func(i int, p path) error {
+				a.Got(i).Expect(len(got)).Same(t)
+				got = append(got, p.path)
+				switch p.path {
+				case "a/":
+					a.Got(p.info.IsDir()).True(t)
+					a.Got(p.depth).Expect(uint32(2)).Same(t)
+				case "b":
+					a.Got(p.info.IsDir()).False(t)
+					a.Got(p.contents).Expect([]line{{lc: 1, content: "bar"}, {lc: 2, content: "baz", matched: true}}).Same(t)
+				}
+				return nil
+			})
+			a.Got(err).NoError(t)
+			a.Got(got).Expect([]string{"a/", "b", "c", "d"}).Same(t)
+		})
+	}
+}
+
+func TestResultStore_Close(t *testing.T) {
+	t.Parallel()
+	info, err := os.Lstat("testdata")
+	a.Got(err).NoError(t)
+
+	rs := newResultStore(1)
+	a.Got(rs.add(path{path: "a", info: fs.FileInfoToDirEntry(info)})).NoError(t)
+	a.Got(len(rs.runs)).Expect(1).Same(t)
+	run := rs.runs[0]
+
+	rs.close()
+	_, err = os.Stat(run)
+	a.Got(os.IsNotExist(err)).True(t)
+}
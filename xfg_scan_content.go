@@ -2,32 +2,85 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sync"
+
+	"github.com/bayashi/xfg/internal/xfgutil"
+)
+
+var (
+	errTooLongLine = errors.New("too long line")
+	errSkipFile    = errors.New("skip file") // not pick up the file
 )
 
+const (
+	lineEndingLF    = "lf"
+	lineEndingCRLF  = "crlf"
+	lineEndingMixed = "mixed"
+)
+
+type lineBreak uint8
+
+const (
+	lineBreakNone lineBreak = iota // the last line without a line break
+	lineBreakLF
+	lineBreakCRLF
+)
+
+// scanBlockBytes is the size of a block to scan contents with a prefilter. It grows for a longer line
+const scanBlockBytes = 64 * 1024
+
+// scanBlockPool reuses blocks among files. Allocating a block for each file is slower than scanning a small file
+var scanBlockPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, scanBlockBytes)
+		return &buf
+	},
+}
+
 type scanFile struct {
 	lc     int32  // line count
-	l      string // line text
+	l      []byte // line text. It is only valid until reading the next line
 	blines []line // slice for before lines
 	aline  uint32 // the count for after lines
+	lf     bool   // has LF line breaks
+	crlf   bool   // has CRLF line breaks
+
+	ranges     []lineRange // resolved line ranges to match
+	outOfRange bool        // current line is out of ranges
 
 	matchedContents []line // result
 }
 
-func (x *xfg) postMatchPath(fPath string, fInfo fs.DirEntry) (err error) {
+func (x *xfg) postMatchPath(ctx context.Context, fPath string, fInfo fs.DirEntry, depth uint32, seq uint64) (err error) {
 	matchedPath := path{
-		info: fInfo,
+		info:  fInfo,
+		depth: depth,
+		seq:   seq,
 	}
 
-	if (len(x.options.SearchGrep) > 0 || len(x.extra.searchGrepRe) > 0) && isRegularFile(fInfo) {
-		matchedPath.contents, err = x.scanFile(fPath)
-		if err != nil {
+	if (len(x.options.SearchGrep) > 0 || len(x.extra.searchGrepRe) > 0 || len(x.options.LineEnding) > 0) && isRegularFile(fInfo) {
+		if x.isOverMaxFilesize(fInfo) {
+			if x.options.Stats {
+				x.cli.stats.IncrSkippedBySize()
+			}
+			return nil // not pick up
+		}
+		matchedPath.contents, err = x.scanFile(ctx, fPath)
+		if errors.Is(err, errSkipFile) {
+			return nil // not pick up
+		} else if err != nil {
 			return fmt.Errorf("scanFile() : %w", err)
 		}
+	} else if len(x.options.LineEnding) > 0 {
+		return nil // not pick up. there are no line breaks
 	}
 
 	if x.options.extra.onlyMatchContent && len(matchedPath.contents) == 0 {
@@ -37,7 +90,7 @@ func (x *xfg) postMatchPath(fPath string, fInfo fs.DirEntry) (err error) {
 	return x.postScanFile(fPath, fInfo, matchedPath)
 }
 
-func (x *xfg) scanFile(fPath string) ([]line, error) {
+func (x *xfg) scanFile(ctx context.Context, fPath string) ([]line, error) {
 	if x.options.Stats {
 		x.cli.stats.IncrScannedFile()
 	}
@@ -54,28 +107,143 @@ func (x *xfg) scanFile(fPath string) ([]line, error) {
 	}
 	defer fh.Close()
 
-	isBinary, err := isBinaryFile(fh)
+	var matchedContents []line
+	if data := x.mapFile(fh); data != nil {
+		defer xfgutil.Munmap(data)
+		if x.options.Stats {
+			x.cli.stats.IncrMappedFile()
+		}
+		matchedContents, err = x.scanMappedFile(ctx, data, fPath)
+	} else {
+		if x.options.Stats {
+			x.cli.stats.IncrReadFile()
+		}
+		matchedContents, err = x.scanReadFile(ctx, fh, fPath)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("path `%s` : %w", fPath, err)
+		if errors.Is(err, errTooLongLine) {
+			x.cli.putErr(fmt.Sprintf("skip `%s` : %s", fPath, err))
+			return nil, nil
+		}
+		return nil, err
 	}
-	if isBinary {
-		return nil, nil
+
+	return matchedContents, nil
+}
+
+// mapFile maps a large regular file into memory. It returns nil to read the file instead
+func (x *xfg) mapFile(fh *os.File) []byte {
+	if x.options.NoMmap {
+		return nil
 	}
 
-	if _, err := fh.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("could not seek `%s` : %w", fPath, err)
+	fi, err := fh.Stat()
+	if err != nil || !fi.Mode().IsRegular() || fi.Size() == 0 || int64(int(fi.Size())) != fi.Size() {
+		return nil
+	}
+	if !x.options.Mmap && fi.Size() < mmapThresholdBytes {
+		return nil
 	}
 
-	matchedContents, err := x.scanContent(bufio.NewScanner(fh), fPath)
+	data, err := xfgutil.Mmap(fh, int(fi.Size()))
 	if err != nil {
-		return nil, fmt.Errorf("scanContent() `%s` : %w", fPath, err)
+		return nil // fall back to read
 	}
 
-	return matchedContents, nil
+	return data
+}
+
+// scanReadFile reads the file only once to scan, unless line ranges need the count of all lines
+func (x *xfg) scanReadFile(ctx context.Context, fh *os.File, fPath string) ([]line, error) {
+	reader := bufio.NewReaderSize(fh, binaryCheckBytes)
+	head, err := reader.Peek(binaryCheckBytes)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not read `%s` : %w", fPath, err)
+	}
+	if isBinary(head) {
+		return x.skipBinaryFile()
+	}
+
+	if x.extra.tailLC > 0 {
+		return x.scanReadTail(ctx, fh, fPath)
+	}
+
+	var totalLC int32
+	if x.extra.needTotalLC {
+		if totalLC, err = countLines(reader); err != nil {
+			return nil, fmt.Errorf("could not count lines `%s` : %w", fPath, err)
+		}
+		if _, err := fh.Seek(0, 0); err != nil {
+			return nil, fmt.Errorf("could not seek `%s` : %w", fPath, err)
+		}
+		reader.Reset(fh)
+	}
+
+	return x.scanContent(ctx, reader, fPath, totalLC, 0)
+}
+
+// scanReadTail seeks to the last lines for line ranges from the end, instead of reading the whole file.
+// Lines before them are counted only to show line numbers
+func (x *xfg) scanReadTail(ctx context.Context, fh *os.File, fPath string) ([]line, error) {
+	fi, err := fh.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("could not stat `%s` : %w", fPath, err)
+	}
+	offset, tailLC, err := findTail(fh, fi.Size(), x.extra.tailLC)
+	if err != nil {
+		return nil, fmt.Errorf("could not read `%s` : %w", fPath, err)
+	}
+
+	var headLC int32
+	if x.needLineNumbers() {
+		if headLC, err = countLines(io.NewSectionReader(fh, 0, offset)); err != nil {
+			return nil, fmt.Errorf("could not count lines `%s` : %w", fPath, err)
+		}
+	}
+	if _, err := fh.Seek(offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("could not seek `%s` : %w", fPath, err)
+	}
+
+	return x.scanContent(ctx, bufio.NewReader(fh), fPath, headLC+tailLC, headLC)
+}
+
+func (x *xfg) scanMappedFile(ctx context.Context, data []byte, fPath string) ([]line, error) {
+	if isBinary(data[:min(len(data), binaryCheckBytes)]) {
+		return x.skipBinaryFile()
+	}
+
+	if x.extra.tailLC > 0 {
+		offset, tailLC, _ := findTail(bytes.NewReader(data), int64(len(data)), x.extra.tailLC) // never fails in memory
+		var headLC int32
+		if x.needLineNumbers() {
+			headLC = countLinesInBytes(data[:offset])
+		}
+		return x.scanMappedContent(ctx, data[offset:], fPath, headLC+tailLC, headLC)
+	}
+
+	var totalLC int32
+	if x.extra.needTotalLC {
+		totalLC = countLinesInBytes(data)
+	}
+
+	return x.scanMappedContent(ctx, data, fPath, totalLC, 0)
+}
+
+// needLineNumbers returns true if line numbers of matched lines are shown
+func (x *xfg) needLineNumbers() bool {
+	return !x.options.NoLineNumber && !x.options.FilesWithMatches && !x.options.ShowMatchCount && !x.options.Quiet
+}
+
+func (x *xfg) skipBinaryFile() ([]line, error) {
+	if len(x.options.LineEnding) > 0 {
+		return nil, errSkipFile
+	}
+
+	return nil, nil // pick up the path without contents
 }
 
 func (x *xfg) postScanFile(fPath string, fInfo fs.DirEntry, matchedPath path) error {
-	if x.options.Abs {
+	if x.options.Abs && fPath != stdinPathName {
 		absPath, err := filepath.Abs(fPath)
 		if err != nil {
 			return fmt.Errorf("failed to get abs path of `%s` : %w", fPath, err)
@@ -89,11 +257,50 @@ func (x *xfg) postScanFile(fPath string, fInfo fs.DirEntry, matchedPath path) er
 
 	matchedPath.path = fPath
 
+	if x.options.KeepResultOrder && x.options.needFileInfoToSort() {
+		if fi, err := fInfo.Info(); err == nil {
+			matchedPath.info = fs.FileInfoToDirEntry(fi) // cache info to sort
+		}
+	}
+
+	if x.ordered != nil {
+		x.ordered.settle(matchedPath.seq, &matchedPath) // accept later in the order of walking
+		return nil
+	}
+
+	return x.acceptResult(matchedPath)
+}
+
+// acceptResult counts the result, then keeps it for ordered output or sends it to streaming output
+func (x *xfg) acceptResult(matchedPath path) error {
 	x.result.mu.Lock()
-	x.result.paths = append(x.result.paths, matchedPath)
+	if x.options.MaxResults > 0 {
+		remaining := int(x.options.MaxResults) - x.result.resultCount
+		if remaining <= 0 {
+			x.result.mu.Unlock()
+			return nil // already enough results
+		}
+		var count int
+		matchedPath, count = x.limitResults(matchedPath, remaining)
+		x.result.resultCount = x.result.resultCount + count
+		if x.result.resultCount >= int(x.options.MaxResults) {
+			x.stop(errMaxResults)
+		}
+	}
+	x.result.pickedCount++
 	x.result.outputLC = x.result.outputLC + len(matchedPath.contents) + 1
+	if x.options.KeepResultOrder && !x.options.Quiet {
+		if err := x.result.paths.add(matchedPath); err != nil {
+			x.result.mu.Unlock()
+			return err
+		}
+	}
 	x.result.mu.Unlock()
 
+	if x.options.Quiet {
+		x.stop(errQuietMatch)
+	}
+
 	// Send to streaming channel if KeepResultOrder is false
 	if !x.options.KeepResultOrder && !x.options.Quiet && x.resultChan != nil {
 		x.resultChan <- matchedPath
@@ -106,32 +313,59 @@ func (x *xfg) postScanFile(fPath string, fInfo fs.DirEntry, matchedPath path) er
 	return nil
 }
 
-func (x *xfg) scanContent(scanner *bufio.Scanner, fPath string) ([]line, error) {
-	gf := &scanFile{
-		lc:     0,
-		blines: make([]line, x.options.extra.actualBeforeContextLines),
-	}
+// nextLineFunc returns the next line, or io.EOF. The line is only valid until the next call
+type nextLineFunc func() (l []byte, lb lineBreak, truncated bool, err error)
 
-	for scanner.Scan() {
-		gf.lc++
-		gf.l = scanner.Text()
-		if err := scanner.Err(); err != nil {
-			return nil, fmt.Errorf("could not scan file `%s` line %d: %w", fPath, gf.lc, err)
+// totalLC is the count of all lines in the content. It is used to resolve negative line ranges.
+// startLC is the count of lines before the content, which are not read
+func (x *xfg) scanContent(ctx context.Context, reader *bufio.Reader, fPath string, totalLC int32, startLC int32) ([]line, error) {
+	return x.scanContentBy(fPath, totalLC, startLC, func(gf *scanFile, lastLC int32) error {
+		if x.canScanBlocks() {
+			return x.scanBlocks(ctx, reader, gf, lastLC)
 		}
+		return x.scanLines(ctx, func() ([]byte, lineBreak, bool, error) {
+			return readLine(reader, int(x.options.MaxLineBytes))
+		}, gf, lastLC)
+	})
+}
 
-		x.processContentLine(gf)
-
-		if x.options.FilesWithMatches && len(gf.matchedContents) > 0 {
-			break
+// scanMappedContent scans contents in memory without copying them
+func (x *xfg) scanMappedContent(ctx context.Context, data []byte, fPath string, totalLC int32, startLC int32) ([]line, error) {
+	return x.scanContentBy(fPath, totalLC, startLC, func(gf *scanFile, lastLC int32) error {
+		if x.canScanBlocks() {
+			return x.scanMappedBlocks(ctx, data, gf, lastLC)
 		}
+		return x.scanLines(ctx, func() ([]byte, lineBreak, bool, error) {
+			if len(data) == 0 {
+				return nil, lineBreakNone, false, io.EOF
+			}
+			l, lb, truncated, rest := splitLine(data, int(x.options.MaxLineBytes))
+			data = rest
+			return l, lb, truncated, nil
+		}, gf, lastLC)
+	})
+}
 
-		if x.options.MaxMatchCount != 0 && int(x.options.MaxMatchCount) <= len(gf.matchedContents) {
-			break
+func (x *xfg) scanContentBy(fPath string, totalLC int32, startLC int32, scan func(gf *scanFile, lastLC int32) error) ([]line, error) {
+	gf := &scanFile{
+		lc:     startLC,
+		blines: make([]line, x.options.extra.actualBeforeContextLines),
+		ranges: resolveLineRanges(x.extra.lineRanges, totalLC),
+	}
+
+	if err := scan(gf, lastLineOfRanges(gf.ranges)); err != nil {
+		if errors.Is(err, errSkipFile) || errors.Is(err, errTooLongLine) {
+			return nil, err
 		}
+		return nil, fmt.Errorf("could not scan file `%s` line %d: %w", fPath, gf.lc+1, err)
+	}
+
+	if len(x.options.LineEnding) > 0 && !x.isMatchLineEnding(gf) {
+		return nil, errSkipFile
 	}
 
 	if x.options.Stats {
-		x.cli.stats.IncrScannedLC(int(gf.lc))
+		x.cli.stats.IncrScannedLC(int(gf.lc - startLC))
 	}
 
 	if x.options.Quiet && !x.result.alreadyMatchContent && len(gf.matchedContents) > 0 {
@@ -141,36 +375,215 @@ func (x *xfg) scanContent(scanner *bufio.Scanner, fPath string) ([]line, error)
 	return gf.matchedContents, nil
 }
 
-func (x *xfg) isMatchLine(line string) bool {
-	if x.options.IgnoreCase && len(x.extra.searchGrepi) > 0 {
-		for _, sgr := range x.extra.searchGrepi {
-			if !isMatchRegexp(line, sgr) {
-				return false
-			}
+// scanLines reads and matches each line
+func (x *xfg) scanLines(ctx context.Context, nextLine nextLineFunc, gf *scanFile, lastLC int32) error {
+	hasGrepKeyword := x.options.hasGrepKeyword()
+	for {
+		if isDone(ctx) {
+			return errSkipFile // stopped halfway. not pick up
 		}
-	} else {
-		if len(x.options.SearchGrep) > 0 {
-			for _, sg := range x.options.SearchGrep {
-				if !isMatch(line, sg) {
-					return false
-				}
+		l, lb, truncated, err := nextLine()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		gf.lc++
+		if truncated && x.options.SkipLongLineFile {
+			return fmt.Errorf("line %d is longer than %d bytes : %w", gf.lc, x.options.MaxLineBytes, errTooLongLine)
+		}
+		gf.l = l
+
+		switch lb {
+		case lineBreakLF:
+			gf.lf = true
+		case lineBreakCRLF:
+			gf.crlf = true
+		}
+
+		if len(x.extra.lineRanges) > 0 {
+			gf.outOfRange = !isInLineRanges(gf.ranges, gf.lc)
+		}
+
+		if hasGrepKeyword {
+			x.processContentLine(gf)
+		}
+
+		if len(x.options.LineEnding) > 0 {
+			if gf.lf && gf.crlf && !hasGrepKeyword {
+				break // enough to know that line breaks are mixed
 			}
+			continue // need to read whole lines to detect line breaks
+		}
+
+		if x.isEnoughScanned(gf, lastLC) {
+			break
 		}
 	}
 
-	if len(x.extra.searchGrepRe) > 0 {
-		for _, re := range x.extra.searchGrepRe {
-			if !isMatchRegexp(line, re) {
-				return false
+	return nil
+}
+
+// canScanBlocks returns true if lines can be skipped without matching. Lines around a matched line
+// and line breaks of all lines are not needed then.
+func (x *xfg) canScanBlocks() bool {
+	withContext := x.options.extra.withBeforeContextLines || x.options.extra.withAfterContextLines
+
+	return x.extra.contentMatcher != nil && x.extra.contentMatcher.prefilter != nil &&
+		(x.options.ShowMatchCount || !withContext) &&
+		len(x.options.LineEnding) == 0 && !x.options.SkipLongLineFile
+}
+
+// scanBlocks reads contents in large blocks, then it matches only lines which have the prefilter.
+// Lines between them are just counted.
+func (x *xfg) scanBlocks(ctx context.Context, reader io.Reader, gf *scanFile, lastLC int32) error {
+	pooled := scanBlockPool.Get().(*[]byte)
+	defer scanBlockPool.Put(pooled)
+	buf := *pooled
+	rest := 0 // bytes of the last line in the previous block without its line break
+	for eof := false; !eof; {
+		if isDone(ctx) {
+			return errSkipFile // stopped halfway. not pick up
+		}
+		if rest == len(buf) {
+			buf = append(buf, make([]byte, len(buf))...) // a line is longer than the block
+		}
+		n, err := io.ReadFull(reader, buf[rest:])
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+
+		block := buf[:rest+n]
+		end := len(block)
+		if !eof {
+			end = bytes.LastIndexByte(block, '\n') + 1 // scan only whole lines
+		}
+		if x.scanBlock(gf, block[:end], lastLC) {
+			break
+		}
+		rest = copy(buf, block[end:])
+	}
+
+	return nil
+}
+
+// scanMappedBlocks scans contents in memory by blocks of whole lines, to stop halfway if needed
+func (x *xfg) scanMappedBlocks(ctx context.Context, data []byte, gf *scanFile, lastLC int32) error {
+	for len(data) > 0 {
+		if isDone(ctx) {
+			return errSkipFile // stopped halfway. not pick up
+		}
+		end := len(data)
+		if end > scanBlockBytes {
+			if i := bytes.LastIndexByte(data[:scanBlockBytes], '\n'); i >= 0 {
+				end = i + 1
+			} else if i := bytes.IndexByte(data[scanBlockBytes:], '\n'); i >= 0 {
+				end = scanBlockBytes + i + 1 // a line is longer than the block
 			}
 		}
+		if x.scanBlock(gf, data[:end], lastLC) {
+			break
+		}
+		data = data[end:]
 	}
 
-	return true // OK, match all
+	return nil
+}
+
+// scanBlock returns true if enough lines are scanned
+func (x *xfg) scanBlock(gf *scanFile, block []byte, lastLC int32) bool {
+	m := x.extra.contentMatcher
+	hasLineRanges := len(x.extra.lineRanges) > 0
+	pos := 0
+	for pos < len(block) {
+		i := m.indexPrefilter(block[pos:])
+		if i < 0 {
+			break
+		}
+		start := pos + bytes.LastIndexByte(block[pos:pos+i], '\n') + 1
+		end := bytes.IndexByte(block[pos+i:], '\n')
+		if end < 0 {
+			end = len(block)
+		} else {
+			end = pos + i + end
+		}
+		gf.lc = gf.lc + int32(bytes.Count(block[pos:start], []byte("\n"))) + 1
+		pos = end + 1
+
+		if hasLineRanges && gf.lc > lastLC {
+			gf.lc = lastLC // lines after the last line range are not scanned
+			return true
+		}
+
+		l := bytes.TrimSuffix(block[start:end], []byte("\r"))
+		if x.options.MaxLineBytes > 0 && len(l) > int(x.options.MaxLineBytes) {
+			l = l[:x.options.MaxLineBytes]
+		}
+		gf.l = l
+		if hasLineRanges {
+			gf.outOfRange = !isInLineRanges(gf.ranges, gf.lc)
+		}
+		x.processContentLine(gf)
+
+		if x.isEnoughScanned(gf, lastLC) {
+			return true
+		}
+	}
+
+	if pos < len(block) {
+		gf.lc = gf.lc + int32(bytes.Count(block[pos:], []byte("\n")))
+		if block[len(block)-1] != '\n' {
+			gf.lc++ // the last line without a line break
+		}
+	}
+
+	if hasLineRanges && gf.lc >= lastLC {
+		gf.lc = lastLC
+		return true
+	}
+
+	return false
+}
+
+func (x *xfg) isEnoughScanned(gf *scanFile, lastLC int32) bool {
+	if x.options.FilesWithMatches && len(gf.matchedContents) > 0 {
+		return true
+	}
+
+	if x.options.MaxMatchCount != 0 && int(x.options.MaxMatchCount) <= len(gf.matchedContents) {
+		return true
+	}
+
+	if len(x.extra.lineRanges) > 0 && gf.lc >= lastLC && gf.aline == 0 {
+		return true // past the last line range
+	}
+
+	return false
+}
+
+func (x *xfg) isMatchLineEnding(gf *scanFile) bool {
+	lineEnding := ""
+	if gf.lf && gf.crlf {
+		lineEnding = lineEndingMixed
+	} else if gf.crlf {
+		lineEnding = lineEndingCRLF
+	} else if gf.lf {
+		lineEnding = lineEndingLF
+	}
+
+	for _, le := range x.options.LineEnding {
+		if le == lineEnding {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (x *xfg) processContentLine(gf *scanFile) {
-	if x.isMatchLine(gf.l) {
+	if !gf.outOfRange && x.extra.contentMatcher.match(gf.l) {
 		if !x.options.ShowMatchCount && x.options.extra.withBeforeContextLines {
 			for _, bl := range gf.blines {
 				if bl.lc == 0 {
@@ -182,11 +595,11 @@ func (x *xfg) processContentLine(gf *scanFile) {
 		}
 
 		if x.options.ShowMatchCount {
-			gf.l = ""
+			gf.l = nil
 		}
 
 		x.optimizeLine(gf)
-		gf.matchedContents = append(gf.matchedContents, line{lc: gf.lc, content: gf.l, matched: true})
+		gf.matchedContents = append(gf.matchedContents, line{lc: gf.lc, content: string(gf.l), matched: true})
 
 		if !x.options.ShowMatchCount && x.options.extra.withAfterContextLines {
 			gf.aline = x.options.extra.actualAfterContextLines // start countdown for `aline`
@@ -196,12 +609,12 @@ func (x *xfg) processContentLine(gf *scanFile) {
 			if x.options.extra.withAfterContextLines && gf.aline > 0 {
 				gf.aline--
 				x.optimizeLine(gf)
-				gf.matchedContents = append(gf.matchedContents, line{lc: gf.lc, content: gf.l})
+				gf.matchedContents = append(gf.matchedContents, line{lc: gf.lc, content: string(gf.l)})
 			} else if x.options.extra.withBeforeContextLines {
 				// rotate blines
 				// join "2nd to last elements of `blines`" and "current `line`"
 				x.optimizeLine(gf)
-				gf.blines = append(gf.blines[1:], line{lc: gf.lc, content: gf.l})
+				gf.blines = append(gf.blines[1:], line{lc: gf.lc, content: string(gf.l)})
 			}
 		}
 	}
@@ -212,3 +625,79 @@ func (x *xfg) optimizeLine(gf *scanFile) {
 		gf.l = gf.l[:x.options.MaxColumns]
 	}
 }
+
+// readLine reads a line of any length without its line break, like bufio.ScanLines does.
+// If maxBytes is more than 0, the rest of the line beyond maxBytes is discarded and truncated is true.
+// The line may refer to the buffer of the reader. It is only valid until the next read.
+func readLine(reader *bufio.Reader, maxBytes int) (l []byte, lb lineBreak, truncated bool, err error) {
+	var buf []byte
+	total := 0      // the length of the line, including the part beyond maxBytes
+	prevCR := false // the previous chunk ends with CR
+	for i := 0; ; i++ {
+		chunk, err := reader.ReadSlice('\n')
+		if err == io.EOF && len(chunk) == 0 && i == 0 {
+			return nil, lineBreakNone, false, io.EOF
+		}
+		last := err != bufio.ErrBufferFull
+		if last {
+			if bytes.HasSuffix(chunk, []byte("\n")) {
+				chunk = chunk[:len(chunk)-1]
+				lb = lineBreakLF
+			}
+			if bytes.HasSuffix(chunk, []byte("\r")) {
+				chunk = chunk[:len(chunk)-1]
+				if lb == lineBreakLF {
+					lb = lineBreakCRLF
+				}
+			} else if len(chunk) == 0 && prevCR && lb == lineBreakLF {
+				lb = lineBreakCRLF // CR at the end of a full buffer
+				total--
+			}
+		} else {
+			prevCR = bytes.HasSuffix(chunk, []byte("\r"))
+		}
+		total += len(chunk)
+		if maxBytes > 0 && len(buf)+len(chunk) > maxBytes {
+			chunk = chunk[:maxBytes-len(buf)]
+		}
+		if i == 0 && last {
+			buf = chunk // no copy for a line in the buffer
+		} else {
+			buf = append(buf, chunk...)
+		}
+		if last {
+			if err != nil && err != io.EOF {
+				return nil, lineBreakNone, false, err
+			}
+			break
+		}
+	}
+
+	if len(buf) > total {
+		buf = buf[:total] // drop CR of CRLF which was split by the buffer
+	}
+	truncated = maxBytes > 0 && total > maxBytes
+
+	return buf, lb, truncated, nil
+}
+
+// splitLine splits the first line from data like readLine does. data must not be empty
+func splitLine(data []byte, maxBytes int) (l []byte, lb lineBreak, truncated bool, rest []byte) {
+	l = data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		l, rest = data[:i], data[i+1:]
+		lb = lineBreakLF
+	}
+	if bytes.HasSuffix(l, []byte("\r")) {
+		l = l[:len(l)-1]
+		if lb == lineBreakLF {
+			lb = lineBreakCRLF
+		}
+	}
+	if maxBytes > 0 && len(l) > maxBytes {
+		l = l[:maxBytes]
+		truncated = true
+	}
+
+	return l, lb, truncated, rest
+}
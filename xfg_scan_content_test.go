@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestReadLine(t *testing.T) {
+	t.Parallel()
+	longLine := strings.Repeat("x", 100000)
+	for tname, tt := range map[string]struct {
+		in              string
+		maxBytes        int
+		bufSize         int
+		expectLines     []string
+		expectBreaks    []lineBreak
+		expectTruncated []bool
+	}{
+		"lines": {
+			in:              "foo\nbar\n\nbaz",
+			expectLines:     []string{"foo", "bar", "", "baz"},
+			expectBreaks:    []lineBreak{lineBreakLF, lineBreakLF, lineBreakLF, lineBreakNone},
+			expectTruncated: []bool{false, false, false, false},
+		},
+		"CRLF": {
+			in:              "foo\r\nbar\r\n",
+			expectLines:     []string{"foo", "bar"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakCRLF},
+			expectTruncated: []bool{false, false},
+		},
+		"mixed": {
+			in:              "foo\r\nbar\nbaz\r",
+			expectLines:     []string{"foo", "bar", "baz"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakLF, lineBreakNone},
+			expectTruncated: []bool{false, false, false},
+		},
+		"longer than the buffer": {
+			in:              longLine + "\nfoo\n",
+			expectLines:     []string{longLine, "foo"},
+			expectBreaks:    []lineBreak{lineBreakLF, lineBreakLF},
+			expectTruncated: []bool{false, false},
+		},
+		"truncated": {
+			in:              longLine + "\nfoo\n",
+			maxBytes:        5,
+			expectLines:     []string{"xxxxx", "foo"},
+			expectBreaks:    []lineBreak{lineBreakLF, lineBreakLF},
+			expectTruncated: []bool{true, false},
+		},
+		"just max bytes": {
+			in:              "12345\r\n123456\n",
+			maxBytes:        5,
+			expectLines:     []string{"12345", "12345"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakLF},
+			expectTruncated: []bool{false, true},
+		},
+		"CRLF split by the buffer": {
+			in:              strings.Repeat("x", 15) + "\r\nfoo\r\n",
+			bufSize:         16,
+			expectLines:     []string{strings.Repeat("x", 15), "foo"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakCRLF},
+			expectTruncated: []bool{false, false},
+		},
+		"CRLF split by the buffer and truncated": {
+			in:              strings.Repeat("x", 15) + "\r\nfoo\r\n",
+			maxBytes:        5,
+			bufSize:         16,
+			expectLines:     []string{"xxxxx", "foo"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakCRLF},
+			expectTruncated: []bool{true, false},
+		},
+		"CRLF split by the buffer with just max bytes": {
+			in:              strings.Repeat("x", 15) + "\r\nfoo\r\n",
+			maxBytes:        15,
+			bufSize:         16,
+			expectLines:     []string{strings.Repeat("x", 15), "foo"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakCRLF},
+			expectTruncated: []bool{false, false},
+		},
+		"CRLF of a long line with truncated": {
+			in:              longLine + "\r\nfoo\n",
+			maxBytes:        5,
+			expectLines:     []string{"xxxxx", "foo"},
+			expectBreaks:    []lineBreak{lineBreakCRLF, lineBreakLF},
+			expectTruncated: []bool{true, false},
+		},
+	} {
+		tt := tt
+		t.Run(tname, func(t *testing.T) {
+			t.Parallel()
+			reader := bufio.NewReader(strings.NewReader(tt.in))
+			if tt.bufSize > 0 {
+				reader = bufio.NewReaderSize(strings.NewReader(tt.in), tt.bufSize)
+			}
+			var lines []string
+			var breaks []lineBreak
+			var truncated []bool
+			for {
+				l, lb, tr, err := readLine(reader, tt.maxBytes)
+				if err == io.EOF {
+					break
+				}
+				a.Got(err).NoError(t)
+				lines = append(lines, string(l))
+				breaks = append(breaks, lb)
+				truncated = append(truncated, tr)
+			}
+			a.Got(lines).Expect(tt.expectLines).Same(t)
+			a.Got(breaks).Expect(tt.expectBreaks).Same(t)
+			a.Got(truncated).Expect(tt.expectTruncated).Same(t)
+
+			lines, breaks, truncated = nil, nil, nil
+			for data := []byte(tt.in); len(data) > 0; {
+				l, lb, tr, rest := splitLine(data, tt.maxBytes)
+				lines = append(lines, string(l))
+				breaks = append(breaks, lb)
+				truncated = append(truncated, tr)
+				data = rest
+			}
+			a.Got(lines).Expect(tt.expectLines).Same(t)
+			a.Got(breaks).Expect(tt.expectBreaks).Same(t)
+			a.Got(truncated).Expect(tt.expectTruncated).Same(t)
+		})
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"bufio"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/bayashi/colorpalette"
@@ -109,15 +108,17 @@ func (cli *runner) showResult(x *xfg) error {
 		lf = "\x00"
 	}
 
-	sort.Slice(x.result.paths, func(i, j int) bool { return x.result.paths[i].path < x.result.paths[j].path })
-
 	if cli.isTTY {
 		if !x.options.NoColor {
 			x.setHighlighter()
 		}
-		cli.outputForTTY(x, lf)
+		if err := cli.outputForTTY(x, lf); err != nil {
+			return err
+		}
 	} else {
-		cli.outputForNonTTY(x, lf)
+		if err := cli.outputForNonTTY(x, lf); err != nil {
+			return err
+		}
 	}
 
 	cli.exitCode = exitOK
@@ -127,9 +128,11 @@ func (cli *runner) showResult(x *xfg) error {
 
 func (cli *runner) outputForTTY(x *xfg, lf string) error {
 	writer := bufio.NewWriter(cli.out)
-	for i, p := range x.result.paths {
+	last := x.result.paths.len() - 1
+
+	return x.result.paths.each(func(i int, p path) error {
 		if x.options.FilesWithMatches && p.info.IsDir() {
-			continue
+			return nil
 		}
 		out := ""
 		if !(x.options.NoFilename && x.options.extra.onlyMatchContent) {
@@ -137,6 +140,7 @@ func (cli *runner) outputForTTY(x *xfg, lf string) error {
 			if !x.options.NoColor {
 				out = x.highlightPath(out)
 			}
+			out = x.depthPrefix(p) + out
 			if x.options.ShowMatchCount && !p.info.IsDir() {
 				out = out + fmt.Sprintf(":%d", len(p.contents))
 			}
@@ -146,7 +150,7 @@ func (cli *runner) outputForTTY(x *xfg, lf string) error {
 		if !x.options.ShowMatchCount && !x.options.FilesWithMatches {
 			if len(p.contents) > 0 {
 				cli.buildContentOutput(x, &out, p.contents, lf)
-				if !(x.options.NoFilename && x.options.extra.onlyMatchContent) && len(x.result.paths)-1 != i {
+				if !(x.options.NoFilename && x.options.extra.onlyMatchContent) && last != i {
 					out = out + lf
 				}
 			}
@@ -156,12 +160,8 @@ func (cli *runner) outputForTTY(x *xfg, lf string) error {
 			x.cli.stats.AddOutputLC(strings.Count(out, lf))
 		}
 
-		if err := xfgutil.Output(writer, out); err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return xfgutil.Output(writer, out)
+	})
 }
 
 func (cli *runner) buildContentOutput(x *xfg, out *string, contents []line, lf string) error {
@@ -189,6 +189,15 @@ func (cli *runner) buildContentOutput(x *xfg, out *string, contents []line, lf s
 	return nil
 }
 
+// depthPrefix returns the depth of the path like `3:` to put in front of the path if --show-depth
+func (x *xfg) depthPrefix(p path) string {
+	if !x.options.ShowDepth {
+		return ""
+	}
+
+	return fmt.Sprintf("%d:", p.depth)
+}
+
 func (x *xfg) needToShowGroupSeparator(blc int32, lc int32) bool {
 	return (x.options.extra.withAfterContextLines || x.options.extra.withBeforeContextLines) && blc != 0 && lc-blc > 1
 }
@@ -230,6 +239,7 @@ func (cli *runner) streamDisplayTTY(x *xfg, lf string) {
 			if !x.options.NoColor {
 				out = x.highlightPath(out)
 			}
+			out = x.depthPrefix(p) + out
 			if x.options.ShowMatchCount && !p.info.IsDir() {
 				out = out + fmt.Sprintf(":%d", len(p.contents))
 			}
@@ -286,13 +296,13 @@ func (cli *runner) streamDisplayNonTTY(x *xfg, lf string) {
 						if x.options.NoFilename && x.options.extra.onlyMatchContent {
 							out = out + fmt.Sprintf("%s%s", l.content, lf)
 						} else {
-							out = out + fmt.Sprintf("%s:%s%s", p.path, l.content, lf)
+							out = out + fmt.Sprintf("%s%s:%s%s", x.depthPrefix(p), p.path, l.content, lf)
 						}
 					} else {
 						if x.options.NoFilename && x.options.extra.onlyMatchContent {
 							out = out + fmt.Sprintf("%d:%s%s", l.lc, l.content, lf)
 						} else {
-							out = out + fmt.Sprintf("%s:%d:%s%s", p.path, l.lc, l.content, lf)
+							out = out + fmt.Sprintf("%s%s:%d:%s%s", x.depthPrefix(p), p.path, l.lc, l.content, lf)
 						}
 					}
 				}
@@ -300,7 +310,7 @@ func (cli *runner) streamDisplayNonTTY(x *xfg, lf string) {
 		} else {
 			if !x.options.FilesWithMatches || !p.info.IsDir() {
 				if !(x.options.NoFilename && x.options.extra.onlyMatchContent) {
-					out = out + fmt.Sprintf("%s%s", p.path, lf)
+					out = out + fmt.Sprintf("%s%s%s", x.depthPrefix(p), p.path, lf)
 				}
 			}
 		}
@@ -317,7 +327,8 @@ func (cli *runner) streamDisplayNonTTY(x *xfg, lf string) {
 
 func (cli *runner) outputForNonTTY(x *xfg, lf string) error {
 	writer := bufio.NewWriter(cli.out)
-	for _, p := range x.result.paths {
+
+	return x.result.paths.each(func(_ int, p path) error {
 		out := ""
 		if len(p.contents) > 0 && !x.options.FilesWithMatches {
 			for _, l := range p.contents {
@@ -326,13 +337,13 @@ func (cli *runner) outputForNonTTY(x *xfg, lf string) error {
 						if x.options.NoFilename && x.options.extra.onlyMatchContent {
 							out = out + fmt.Sprintf("%s%s", l.content, lf)
 						} else {
-							out = out + fmt.Sprintf("%s:%s%s", p.path, l.content, lf)
+							out = out + fmt.Sprintf("%s%s:%s%s", x.depthPrefix(p), p.path, l.content, lf)
 						}
 					} else {
 						if x.options.NoFilename && x.options.extra.onlyMatchContent {
 							out = out + fmt.Sprintf("%d:%s%s", l.lc, l.content, lf)
 						} else {
-							out = out + fmt.Sprintf("%s:%d:%s%s", p.path, l.lc, l.content, lf)
+							out = out + fmt.Sprintf("%s%s:%d:%s%s", x.depthPrefix(p), p.path, l.lc, l.content, lf)
 						}
 					}
 				}
@@ -340,7 +351,7 @@ func (cli *runner) outputForNonTTY(x *xfg, lf string) error {
 		} else {
 			if !x.options.FilesWithMatches || !p.info.IsDir() {
 				if !(x.options.NoFilename && x.options.extra.onlyMatchContent) {
-					out = out + fmt.Sprintf("%s%s", p.path, lf)
+					out = out + fmt.Sprintf("%s%s%s", x.depthPrefix(p), p.path, lf)
 				}
 			}
 		}
@@ -349,10 +360,6 @@ func (cli *runner) outputForNonTTY(x *xfg, lf string) error {
 			x.cli.stats.AddOutputLC(strings.Count(out, lf))
 		}
 
-		if err := xfgutil.Output(writer, out); err != nil {
-			return err
-		}
-	}
-
-	return nil
+		return xfgutil.Output(writer, out)
+	})
 }
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type sizeCondition struct {
+	op   byte // '+': at least, '-': at most, 0: exactly
+	size int64
+}
+
+func (sc sizeCondition) match(size int64) bool {
+	switch sc.op {
+	case '+':
+		return size >= sc.size
+	case '-':
+		return size <= sc.size
+	default:
+		return size == sc.size
+	}
+}
+
+// parseSizeConditions parses conditions like "+1M", "-10k" or "512"
+func parseSizeConditions(sizes []string) ([]sizeCondition, error) {
+	scs := make([]sizeCondition, 0, len(sizes))
+	for _, s := range sizes {
+		sc := sizeCondition{}
+		if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+			sc.op = s[0]
+			s = s[1:]
+		}
+		size, err := parseSize(s)
+		if err != nil {
+			return nil, err
+		}
+		sc.size = size
+		scs = append(scs, sc)
+	}
+
+	return scs, nil
+}
+
+// parseSize parses a size like "100", "10k", "1M" or "2G". Units are based on 1024. "kb" is the same as "k"
+func parseSize(s string) (int64, error) {
+	unit := int64(1)
+	num := s
+	if l := strings.ToLower(s); len(l) > 2 && l[len(l)-1] == 'b' && strings.ContainsRune("kmgt", rune(l[len(l)-2])) {
+		num = s[:len(s)-1]
+	}
+	if len(num) > 0 {
+		switch strings.ToLower(num[len(num)-1:]) {
+		case "b":
+			num = num[:len(num)-1]
+		case "k":
+			unit = 1 << 10
+			num = num[:len(num)-1]
+		case "m":
+			unit = 1 << 20
+			num = num[:len(num)-1]
+		case "g":
+			unit = 1 << 30
+			num = num[:len(num)-1]
+		case "t":
+			unit = 1 << 40
+			num = num[:len(num)-1]
+		}
+	}
+
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("wrong size `%s`. e.g. 100, 10k, 1M, 2G", s)
+	}
+	if n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("too large size `%s`", s)
+	}
+
+	return n * unit, nil
+}
+
+func (x *xfg) isMatchSize(fInfo fs.DirEntry) bool {
+	if !fInfo.Type().IsRegular() {
+		return false // size conditions are only for regular files
+	}
+
+	i, err := fInfo.Info()
+	if err != nil {
+		return false // trap error
+	}
+
+	for _, sc := range x.extra.sizeConditions {
+		if !sc.match(i.Size()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (x *xfg) isOverMaxFilesize(fInfo fs.DirEntry) bool {
+	if x.extra.maxFilesize <= 0 {
+		return false
+	}
+
+	i, err := fInfo.Info()
+	if err != nil {
+		return false // trap error
+	}
+
+	return i.Size() > x.extra.maxFilesize
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestParseSize(t *testing.T) {
+	t.Parallel()
+	for s, expect := range map[string]int64{
+		"0":     0,
+		"100":   100,
+		"100b":  100,
+		"10k":   10 * 1024,
+		"10K":   10 * 1024,
+		"1M":    1024 * 1024,
+		"2g":    2 * 1024 * 1024 * 1024,
+		"10kb":  10 * 1024,
+		"1MB":   1024 * 1024,
+		"3Gb":   3 * 1024 * 1024 * 1024,
+		"1tB":   1024 * 1024 * 1024 * 1024,
+		"8191T": 8191 * 1024 * 1024 * 1024 * 1024,
+	} {
+		size, err := parseSize(s)
+		a.Got(err).Debug("size", s).NoError(t)
+		a.Got(size).Expect(expect).Debug("size", s).Same(t)
+	}
+
+	for s, expect := range map[string]string{
+		"1X":                   "wrong size `1X`",
+		"":                     "wrong size ``",
+		"b":                    "wrong size `b`",
+		"kb":                   "wrong size `kb`",
+		"1bb":                  "wrong size `1bb`",
+		"-1k":                  "wrong size `-1k`",
+		"9999999999G":          "too large size `9999999999G`",
+		"8388608T":             "too large size `8388608T`",
+		"99999999999999999999": "wrong size `99999999999999999999`",
+	} {
+		_, err := parseSize(s)
+		a.Got(err).Expect(expect).Debug("size", s).Match(t)
+	}
+}
+
+func TestParseSizeConditions(t *testing.T) {
+	t.Parallel()
+	scs, err := parseSizeConditions([]string{"+1k", "-2k", "512"})
+	a.Got(err).NoError(t)
+	a.Got(scs).Expect([]sizeCondition{{op: '+', size: 1024}, {op: '-', size: 2048}, {size: 512}}).Same(t)
+	a.Got(scs[0].match(1024)).True(t)
+	a.Got(scs[0].match(1023)).False(t)
+	a.Got(scs[1].match(2049)).False(t)
+	a.Got(scs[2].match(512)).True(t)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sortByPath    = "path"
+	sortByName    = "name"
+	sortByMtime   = "mtime"
+	sortBySize    = "size"
+	sortByMatches = "matches"
+	sortByDepth   = "depth"
+	sortByExt     = "ext"
+)
+
+func validateSortKey(key string) error {
+	switch key {
+	case sortByPath, sortByName, sortByMtime, sortBySize, sortByMatches, sortByDepth, sortByExt:
+		return nil
+	}
+
+	return fmt.Errorf("wrong sort key `%s`. Supported: %s", key, supportSortKeys)
+}
+
+// sortKey returns the key to sort results, and whether it is reversed
+func (o *options) sortKey() (string, bool) {
+	if o.SortR != "" {
+		return o.SortR, true
+	} else if o.Sort != "" {
+		return o.Sort, false
+	}
+
+	return sortByPath, false
+}
+
+func (o *options) needFileInfoToSort() bool {
+	key, _ := o.sortKey()
+
+	return key == sortByMtime || key == sortBySize
+}
+
+// sortLess returns the function to sort results. Ties are sorted by path
+func (x *xfg) sortLess() func(a path, b path) bool {
+	key, reverse := x.options.sortKey()
+	comparePath := strings.Compare
+	if x.options.SortNatural {
+		comparePath = naturalCompare
+	}
+
+	return func(a path, b path) bool {
+		if x.options.DirsFirst && a.info.IsDir() != b.info.IsDir() {
+			return a.info.IsDir()
+		}
+
+		c := compareByKey(key, a, b, comparePath)
+		if c == 0 {
+			c = comparePath(a.path, b.path)
+		}
+		if reverse {
+			c = -c
+		}
+
+		return c < 0
+	}
+}
+
+func compareByKey(key string, a path, b path, comparePath func(a string, b string) int) int {
+	switch key {
+	case sortByName:
+		return comparePath(a.info.Name(), b.info.Name())
+	case sortByMtime:
+		return infoOf(a).ModTime().Compare(infoOf(b).ModTime())
+	case sortBySize:
+		return compareInt(infoOf(a).Size(), infoOf(b).Size())
+	case sortByMatches:
+		return compareInt(countMatchedLines(a.contents), countMatchedLines(b.contents))
+	case sortByDepth:
+		return compareInt(a.depth, b.depth)
+	case sortByExt:
+		return strings.Compare(filepath.Ext(a.info.Name()), filepath.Ext(b.info.Name()))
+	}
+
+	return 0 // path. compared as a tie
+}
+
+// infoOf returns empty info on error, so the path is sorted as the oldest and the smallest
+func infoOf(p path) fs.FileInfo {
+	fi, err := p.info.Info()
+	if err != nil {
+		return stdinInfo{}
+	}
+
+	return fi
+}
+
+func compareInt[T int | int64 | uint32](a T, b T) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+
+	return 0
+}
+
+func countMatchedLines(contents []line) int {
+	count := 0
+	for _, l := range contents {
+		if l.matched {
+			count++
+		}
+	}
+
+	return count
+}
+
+// naturalCompare compares strings treating runs of digits as numbers, like `file2` < `file10`
+func naturalCompare(a string, b string) int {
+	for a != "" && b != "" {
+		if isDigit(a[0]) && isDigit(b[0]) {
+			na, ra := splitDigits(a)
+			nb, rb := splitDigits(b)
+			if c := compareDigits(na, nb); c != 0 {
+				return c
+			}
+			a, b = ra, rb
+			continue
+		}
+		if a[0] != b[0] {
+			return compareInt(int(a[0]), int(b[0]))
+		}
+		a, b = a[1:], b[1:]
+	}
+
+	return compareInt(len(a), len(b))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func splitDigits(s string) (string, string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+// compareDigits compares numbers of any length. Leading zeros are ignored, then fewer zeros are first
+func compareDigits(a string, b string) int {
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if c := compareInt(len(ta), len(tb)); c != 0 {
+		return c
+	}
+	if c := strings.Compare(ta, tb); c != 0 {
+		return c
+	}
+
+	return compareInt(len(a), len(b))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestNaturalCompare(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		a      string
+		b      string
+		expect int
+	}{
+		{a: "file2", b: "file10", expect: -1},
+		{a: "file10", b: "file2", expect: 1},
+		{a: "file2", b: "file2", expect: 0},
+		{a: "file02", b: "file2", expect: 1},
+		{a: "a1b2", b: "a1b10", expect: -1},
+		{a: "abc", b: "abd", expect: -1},
+		{a: "ab", b: "abc", expect: -1},
+		{a: "x99999999999999999999", b: "x100000000000000000000", expect: -1},
+	} {
+		a.Got(naturalCompare(tt.a, tt.b)).Expect(tt.expect).X().Debug("a", tt.a).Debug("b", tt.b).Same(t)
+	}
+}
+
+func TestValidateSortKey(t *testing.T) {
+	t.Parallel()
+	for _, key := range []string{"path", "name", "mtime", "size", "matches", "depth", "ext"} {
+		a.Got(validateSortKey(key)).NoError(t)
+	}
+	a.Got(validateSortKey("foo")).Expect("wrong sort key `foo`").Match(t)
+}
+
+func TestSortLess(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	entry := func(name string, size int, mtime time.Time) fs.DirEntry {
+		p := filepath.Join(tempDir, name)
+		a.Got(os.WriteFile(p, make([]byte, size), 0644)).NoError(t)
+		a.Got(os.Chtimes(p, mtime, mtime)).NoError(t)
+		fi, err := os.Lstat(p)
+		a.Got(err).NoError(t)
+		return fs.FileInfoToDirEntry(fi)
+	}
+	dirInfo, err := os.Lstat(tempDir)
+	a.Got(err).NoError(t)
+
+	now := time.Now()
+	matched := line{matched: true}
+	paths := []path{
+		{path: "b/file10.txt", info: entry("file10.txt", 30, now.Add(-1*time.Hour)), depth: 2},
+		{path: "a/file2.go", info: entry("file2.go", 10, now), depth: 2, contents: []line{matched, matched}},
+		{path: "c.md", info: entry("c.md", 20, now.Add(-2*time.Hour)), depth: 1, contents: []line{matched}},
+		{path: "d/", info: fs.FileInfoToDirEntry(dirInfo), depth: 1},
+	}
+
+	for tname, tt := range map[string]struct {
+		opt    *options
+		expect []string
+	}{
+		"default": {
+			opt:    &options{},
+			expect: []string{"a/file2.go", "b/file10.txt", "c.md", "d/"},
+		},
+		"path reversed": {
+			opt:    &options{SortR: "path"},
+			expect: []string{"d/", "c.md", "b/file10.txt", "a/file2.go"},
+		},
+		"name natural": {
+			opt:    &options{Sort: "name", SortNatural: true},
+			expect: []string{"d/", "c.md", "a/file2.go", "b/file10.txt"}, // the name of d/ is like "001"
+		},
+		"mtime reversed": {
+			opt:    &options{SortR: "mtime"},
+			expect: []string{"a/file2.go", "b/file10.txt", "c.md"},
+		},
+		"size": {
+			opt:    &options{Sort: "size"},
+			expect: []string{"a/file2.go", "c.md", "b/file10.txt"},
+		},
+		"matches reversed": {
+			opt:    &options{SortR: "matches"},
+			expect: []string{"a/file2.go", "c.md", "d/", "b/file10.txt"},
+		},
+		"depth": {
+			opt:    &options{Sort: "depth"},
+			expect: []string{"c.md", "d/", "a/file2.go", "b/file10.txt"},
+		},
+		"ext": {
+			opt:    &options{Sort: "ext"},
+			expect: []string{"d/", "a/file2.go", "c.md", "b/file10.txt"},
+		},
+		"dirs first": {
+			opt:    &options{DirsFirst: true},
+			expect: []string{"d/", "a/file2.go", "b/file10.txt", "c.md"},
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			t.Parallel()
+			x := &xfg{options: tt.opt}
+			less := x.sortLess()
+			sorted := append([]path{}, paths...)
+			sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+			var got []string
+			for _, p := range sorted {
+				if !p.info.IsDir() || len(tt.expect) == len(paths) {
+					got = append(got, p.path)
+				}
+			}
+			a.Got(got).Expect(tt.expect).Same(t)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prepareStartPaths expands glob patterns in start paths, and `~` and environment variables only in
+// start paths from .xfgrc, because a shell already expanded them on the command line.
+// Then it removes overlapping start paths
+func (o *options) prepareStartPaths(homeDir string) error {
+	if o.FilesFrom != "" {
+		return nil // paths come from --files-from instead
+	}
+
+	var startPaths []string
+	for _, sp := range o.SearchStart {
+		if sp == stdinStartPath {
+			startPaths = append(startPaths, sp)
+			continue
+		}
+		if !o.extra.searchStartGiven {
+			sp = expandHomeDir(os.ExpandEnv(sp), homeDir)
+		}
+		if !hasGlobMeta(sp) || existsPath(sp) {
+			startPaths = append(startPaths, filepath.Clean(sp))
+			continue
+		}
+		matches, err := filepath.Glob(sp)
+		if err != nil {
+			return fmt.Errorf("wrong glob pattern `%s` : %w", sp, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("no paths match the pattern `%s`", sp)
+		}
+		startPaths = append(startPaths, matches...)
+	}
+
+	o.SearchStart = dedupeStartPaths(startPaths)
+
+	return nil
+}
+
+func expandHomeDir(p string, homeDir string) string {
+	if homeDir == "" {
+		return p
+	}
+
+	if p == "~" {
+		return homeDir
+	} else if strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "~"+string(filepath.Separator)) {
+		return filepath.Join(homeDir, p[2:])
+	}
+
+	return p
+}
+
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// existsPath is used to treat an existing path like `foo[1]` literally, even if it has glob meta characters
+func existsPath(p string) bool {
+	_, err := os.Lstat(p)
+	return err == nil
+}
+
+// dedupeStartPaths removes the same paths and paths under other start directories,
+// so that the same file is not reported twice
+func dedupeStartPaths(startPaths []string) []string {
+	absPaths := make([]string, len(startPaths))
+	for i, sp := range startPaths {
+		if sp == stdinStartPath {
+			continue
+		}
+		abs, err := filepath.Abs(sp)
+		if err != nil {
+			abs = sp // trap error. just compare as it is
+		}
+		absPaths[i] = abs
+	}
+
+	var deduped []string
+	for i, sp := range startPaths {
+		if !isOverlappedStartPath(i, absPaths) {
+			deduped = append(deduped, sp)
+		}
+	}
+
+	return deduped
+}
+
+func isOverlappedStartPath(i int, absPaths []string) bool {
+	if absPaths[i] == "" {
+		return false // stdin
+	}
+
+	for j, other := range absPaths {
+		if j == i || other == "" {
+			continue
+		}
+		if other == absPaths[i] && j < i {
+			return true // keep the first one
+		}
+		if isUnderDir(absPaths[i], other) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isUnderDir(p string, dir string) bool {
+	if !strings.HasSuffix(dir, string(filepath.Separator)) {
+		dir = dir + string(filepath.Separator)
+	}
+
+	return p != dir && strings.HasPrefix(p, dir)
+}
+
+// walkStartFile searches a file which is given as a start path directly.
+// Like rg and fd, it is searched even if it is hidden, skipped by default, ignored or shallower than --min-depth
+func (x *xfg) walkStartFile(ctx context.Context, wp *workerPool, fPath string) error {
+	fi, err := os.Lstat(fPath)
+	if err != nil {
+		return err
+	}
+
+	return x.walkFile(ctx, wp, fPath, fs.FileInfoToDirEntry(fi), nil, 0, true)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestPrepareStartPaths(t *testing.T) {
+	t.Setenv("XFG_TEST_START", "testdata")
+
+	for tname, tt := range map[string]struct {
+		start   []string
+		cmdLine bool
+		expect  []string
+	}{
+		"as it is": {
+			start:  []string{"testdata/service-a"},
+			expect: []string{"testdata/service-a"},
+		},
+		"glob": {
+			start:  []string{"testdata/service-[ab]/main.go"},
+			expect: []string{"testdata/service-a/main.go", "testdata/service-b/main.go"},
+		},
+		"env": {
+			start:  []string{"$XFG_TEST_START/service-a"},
+			expect: []string{"testdata/service-a"},
+		},
+		"home dir": {
+			start:  []string{"~/service-a"},
+			expect: []string{"testdata/service-a"},
+		},
+		"dedupe same paths": {
+			start:  []string{"testdata/service-a", "./testdata/service-a/"},
+			expect: []string{"testdata/service-a"},
+		},
+		"dedupe nested paths": {
+			start:  []string{"testdata/service-a/main.go", "testdata", "testdata/service-b"},
+			expect: []string{"testdata"},
+		},
+		"stdin": {
+			start:  []string{"-", "testdata"},
+			expect: []string{"-", "testdata"},
+		},
+		"env and home dir are not expanded on the command line": {
+			start:   []string{"$XFG_TEST_START/service-a", "~/service-a"},
+			cmdLine: true,
+			expect:  []string{"$XFG_TEST_START/service-a", "~/service-a"},
+		},
+		"glob on the command line": {
+			start:   []string{"testdata/service-[ab]/main.go"},
+			cmdLine: true,
+			expect:  []string{"testdata/service-a/main.go", "testdata/service-b/main.go"},
+		},
+	} {
+		t.Run(tname, func(t *testing.T) {
+			o := &options{SearchStart: tt.start}
+			o.extra.searchStartGiven = tt.cmdLine
+			err := o.prepareStartPaths("testdata")
+			a.Got(err).NoError(t)
+			for i := range tt.expect {
+				tt.expect[i] = filepath.FromSlash(tt.expect[i])
+			}
+			a.Got(o.SearchStart).Expect(tt.expect).Same(t)
+		})
+	}
+}
+
+func TestPrepareStartPaths_Err(t *testing.T) {
+	o := &options{SearchStart: []string{"testdata/no-such-*"}}
+	err := o.prepareStartPaths("")
+	a.Got(err).Expect("no paths match the pattern `testdata/no-such-\\*`").Match(t)
+}
+
+func TestPrepareStartPaths_LiteralPath(t *testing.T) {
+	root := t.TempDir()
+	writeTestFiles(t, root, map[string]string{
+		"a[1]/main.go": "foo\n",
+		"a1/main.go":   "foo\n",
+	})
+
+	o := &options{SearchStart: []string{filepath.Join(root, "a[1]")}}
+	err := o.prepareStartPaths("")
+	a.Got(err).NoError(t)
+	a.Got(o.SearchStart).Expect([]string{filepath.Join(root, "a[1]")}).Same(t)
+}
+
+func TestIsUnderDir(t *testing.T) {
+	a.Got(isUnderDir(filepath.FromSlash("/foo/bar"), filepath.FromSlash("/foo"))).True(t)
+	a.Got(isUnderDir(filepath.FromSlash("/foo/bar"), filepath.FromSlash("/"))).True(t)
+	a.Got(isUnderDir(filepath.FromSlash("/foobar"), filepath.FromSlash("/foo"))).False(t)
+	a.Got(isUnderDir(filepath.FromSlash("/foo"), filepath.FromSlash("/foo"))).False(t)
+}
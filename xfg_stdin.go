@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// stdinEntry is a pseudo fs.DirEntry to treat standard input as a file
+type stdinEntry struct{}
+
+func (stdinEntry) Name() string               { return stdinPathName }
+func (stdinEntry) IsDir() bool                { return false }
+func (stdinEntry) Type() fs.FileMode          { return 0 }
+func (stdinEntry) Info() (fs.FileInfo, error) { return stdinInfo{}, nil }
+
+type stdinInfo struct{}
+
+func (stdinInfo) Name() string       { return stdinPathName }
+func (stdinInfo) Size() int64        { return 0 }
+func (stdinInfo) Mode() fs.FileMode  { return 0 }
+func (stdinInfo) ModTime() time.Time { return time.Time{} }
+func (stdinInfo) IsDir() bool        { return false }
+func (stdinInfo) Sys() any           { return nil }
+
+func (x *xfg) scanStdin(ctx context.Context, seq uint64) error {
+	if x.cli.in == nil {
+		return nil
+	}
+
+	if x.options.Stats {
+		x.cli.stats.IncrScannedFile()
+		x.cli.stats.IncrReadFile()
+	}
+
+	var totalLC int32
+	in := x.cli.in
+	if x.extra.needTotalLC {
+		dat, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("could not read `%s` : %w", stdinPathName, err)
+		}
+		totalLC = countLinesInBytes(dat)
+		in = bytes.NewReader(dat)
+	}
+
+	reader := bufio.NewReaderSize(in, binaryCheckBytes)
+	head, err := reader.Peek(binaryCheckBytes)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("could not read `%s` : %w", stdinPathName, err)
+	}
+	if len(head) == 0 {
+		return nil // empty input
+	}
+	if isBinary(head) {
+		return nil
+	}
+
+	var startLC int32
+	if x.extra.tailLC > 0 {
+		// keep only the last lines in memory, because standard input can not seek
+		tail, before, err := readTail(reader, x.extra.tailLC)
+		if err != nil {
+			return fmt.Errorf("could not read `%s` : %w", stdinPathName, err)
+		}
+		startLC = before
+		totalLC = before + countLinesInBytes(tail)
+		reader = bufio.NewReader(bytes.NewReader(tail))
+	}
+
+	matchedContents, err := x.scanContent(ctx, reader, stdinPathName, totalLC, startLC)
+	if err != nil {
+		if errors.Is(err, errSkipFile) {
+			return nil
+		}
+		if errors.Is(err, errTooLongLine) {
+			x.cli.putErr(fmt.Sprintf("skip `%s` : %s", stdinPathName, err))
+			return nil
+		}
+		return fmt.Errorf("scanContent() `%s` : %w", stdinPathName, err)
+	}
+
+	if len(matchedContents) == 0 {
+		return nil // not pick up
+	}
+
+	return x.postScanFile(stdinPathName, stdinEntry{}, path{info: stdinEntry{}, contents: matchedContents, seq: seq})
+}
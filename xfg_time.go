@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseTimeCondition parses a duration like "2d", "1h30m" or "1w" as the time before now,
+// or a date like "2026-01-01" or "2026-01-01 12:00:00" in local time
+func parseTimeCondition(s string, now time.Time) (time.Time, error) {
+	if d, err := parseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("wrong time `%s`. e.g. 30m, 2h, 3d, 1w, 2026-01-01 or '2026-01-01 12:00:00'", s)
+}
+
+// parseDuration supports "d" (day) and "w" (week) in addition to time.ParseDuration
+func parseDuration(s string) (time.Duration, error) {
+	for unit, d := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, unit); ok {
+			i, err := strconv.ParseUint(n, 10, 32)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(i) * d, nil
+		}
+	}
+
+	return time.ParseDuration(s)
+}
+
+func modTimeOf(fPath string) (time.Time, error) {
+	fi, err := os.Stat(fPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return fi.ModTime(), nil
+}
+
+func (x *xfg) prepareTimeConditions() error {
+	now := time.Now()
+
+	if x.options.ChangedWithin != "" {
+		t, err := parseTimeCondition(x.options.ChangedWithin, now)
+		if err != nil {
+			return err
+		}
+		x.extra.changedAfter = t
+	}
+
+	if x.options.ChangedBefore != "" {
+		t, err := parseTimeCondition(x.options.ChangedBefore, now)
+		if err != nil {
+			return err
+		}
+		x.extra.changedBefore = t
+	}
+
+	if x.options.Newer != "" {
+		t, err := modTimeOf(x.options.Newer)
+		if err != nil {
+			return fmt.Errorf("--newer : %w", err)
+		}
+		if t.After(x.extra.changedAfter) {
+			x.extra.changedAfter = t
+		}
+	}
+
+	if x.options.Older != "" {
+		t, err := modTimeOf(x.options.Older)
+		if err != nil {
+			return fmt.Errorf("--older : %w", err)
+		}
+		if x.extra.changedBefore.IsZero() || t.Before(x.extra.changedBefore) {
+			x.extra.changedBefore = t
+		}
+	}
+
+	return nil
+}
+
+func (x *xfg) hasTimeCondition() bool {
+	return !x.extra.changedAfter.IsZero() || !x.extra.changedBefore.IsZero()
+}
+
+func (x *xfg) isMatchModTime(fInfo fs.DirEntry) bool {
+	i, err := fInfo.Info()
+	if err != nil {
+		return false // trap error
+	}
+
+	mt := i.ModTime()
+	if !x.extra.changedAfter.IsZero() && !mt.After(x.extra.changedAfter) {
+		return false
+	}
+	if !x.extra.changedBefore.IsZero() && !mt.Before(x.extra.changedBefore) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	a "github.com/bayashi/actually"
+)
+
+func TestParseTimeCondition(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+	for s, expect := range map[string]time.Time{
+		"30m":                 now.Add(-30 * time.Minute),
+		"1h30m":               now.Add(-90 * time.Minute),
+		"2d":                  now.Add(-48 * time.Hour),
+		"1w":                  now.Add(-7 * 24 * time.Hour),
+		"2026-01-01":          time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local),
+		"2026-01-01 12:34:56": time.Date(2026, 1, 1, 12, 34, 56, 0, time.Local),
+	} {
+		got, err := parseTimeCondition(s, now)
+		a.Got(err).NoError(t)
+		a.Got(got.Equal(expect)).Debug("time", s).True(t)
+	}
+
+	_, err := parseTimeCondition("yesterday", now)
+	a.Got(err).Expect("wrong time `yesterday`").Match(t)
+}